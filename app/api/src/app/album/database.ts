@@ -8,7 +8,7 @@ type SortBy = z.TypeOf<typeof SortBy>;
 
 export const find = (artist: Artist) =>
   prisma.album.findMany({
-    where: { artist: { url: artist.url } },
+    where: { artist: { url: artist.url }, deletedAt: null },
   });
 
 const Rating = z.string().pipe(z.coerce.number().min(0).max(10));
@@ -57,7 +57,9 @@ export const search = ({
       FROM Album al
         INNER JOIN Artist ar       ON al.artistUrl = ar.url
         INNER JOIN UpdateHistory h ON al.pageUrl = h.pageUrl
-      WHERE (${name} ISNULL OR al.name LIKE '%' || ${name} || '%'
+      WHERE al.deletedAt ISNULL
+        AND ar.deletedAt ISNULL
+        AND (${name} ISNULL OR al.name LIKE '%' || ${name} || '%'
                             OR ar.name LIKE '%' || ${name} || '%')
         AND (${yearMin} ISNULL OR al.year >= ${yearMin})
         AND (${yearMax} ISNULL OR al.year <= ${yearMax})
@@ -76,7 +78,9 @@ export const search = ({
     const [total] = await tx.$queryRaw<{ count: bigint }[]>`
       SELECT COUNT(*) AS count
       FROM Album al INNER JOIN Artist ar ON al.artistUrl = ar.url
-      WHERE (${name} ISNULL OR al.name LIKE '%' || ${name ?? ""} || '%'
+      WHERE al.deletedAt ISNULL
+        AND ar.deletedAt ISNULL
+        AND (${name} ISNULL OR al.name LIKE '%' || ${name ?? ""} || '%'
                             OR ar.name LIKE '%' || ${name ?? ""} || '%')
         AND (${yearMin} ISNULL OR al.year >= ${yearMin})
         AND (${yearMax} ISNULL OR al.year <= ${yearMax})
@@ -94,4 +98,5 @@ export const search = ({
     };
   });
 
-export const getCount = () => prisma.album.count({});
+export const getCount = () =>
+  prisma.album.count({ where: { deletedAt: null } });
@@ -4,13 +4,13 @@ import { ItemsPerPage, Page } from "../validation";
 
 export const getName = (artistUrl: string) =>
   prisma.artist.findUnique({
-    where: { url: artistUrl },
+    where: { url: artistUrl, deletedAt: null },
     select: { name: true },
   });
 
 export const get = (artistUrl: string) =>
   prisma.artist.findUnique({
-    where: { url: artistUrl },
+    where: { url: artistUrl, deletedAt: null },
     select: {
       url: true,
       imageUrl: true,
@@ -18,11 +18,16 @@ export const get = (artistUrl: string) =>
       name: true,
       toRelatedArtists: { select: { url: true, name: true } },
       fromRelatedArtists: { select: { url: true, name: true } },
-      albums: { include: {}, orderBy: { year: "asc" } },
+      albums: {
+        include: {},
+        where: { deletedAt: null },
+        orderBy: { year: "asc" },
+      },
     },
   });
 
-export const getCount = () => prisma.artist.count();
+export const getCount = () =>
+  prisma.artist.count({ where: { deletedAt: null } });
 
 export const SearchRequest = z.object({
   name: z.string().optional(),
@@ -44,14 +49,15 @@ export const search = async ({
       { name: string; url: string; imageUrl?: string; lastModified: Date }[]
     >`SELECT name, url, imageUrl, lastModified
       FROM Artist
-      WHERE ${name} ISNULL OR name LIKE "%" || ${name ?? ""} || "%"
+      WHERE deletedAt ISNULL
+        AND (${name} ISNULL OR name LIKE "%" || ${name ?? ""} || "%")
       ORDER BY
         (CASE WHEN name = ${name} THEN 1 WHEN name LIKE ${name} || "%" THEN 2 ELSE 3 END),
         (CASE WHEN ${sort} = 'lastModified' THEN -lastModified ELSE name COLLATE NOCASE END) ASC
       LIMIT ${itemsPerPage} OFFSET ${itemsPerPage * page}`;
 
     const total = await tx.artist.count({
-      where: { name: { contains: name } },
+      where: { name: { contains: name }, deletedAt: null },
     });
     return { data, total };
   });
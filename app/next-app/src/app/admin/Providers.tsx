@@ -15,7 +15,15 @@ const ArtistProvidersAsync = async () => {
   return (
     <Fields
       type="artist"
-      labels={{ spotify: "Spotify", deezer: "Deezer" }}
+      labels={{
+        spotify: "Spotify",
+        deezer: "Deezer",
+        lastfm: "last.fm",
+        discogs: "Discogs",
+        wikidata: "Wikidata",
+        itunes: "iTunes",
+        bandcamp: "Bandcamp",
+      }}
       values={res}
     />
   );
@@ -35,6 +43,9 @@ const AlbumProvidersAsync = async () => {
         deezer: "Deezer",
         musicbrainz: "MusicBrainz",
         lastfm: "last.fm",
+        discogs: "Discogs",
+        itunes: "iTunes",
+        bandcamp: "Bandcamp",
       }}
       values={res}
     />
@@ -47,6 +58,11 @@ const action = async (formData: FormData) => {
   const artist: ArtistProviders = {
     spotify: formData.get("artist-spotify") === "on",
     deezer: formData.get("artist-deezer") === "on",
+    lastfm: formData.get("artist-lastfm") === "on",
+    discogs: formData.get("artist-discogs") === "on",
+    wikidata: formData.get("artist-wikidata") === "on",
+    itunes: formData.get("artist-itunes") === "on",
+    bandcamp: formData.get("artist-bandcamp") === "on",
   };
 
   const album: AlbumProviders = {
@@ -54,6 +70,9 @@ const action = async (formData: FormData) => {
     deezer: formData.get("album-deezer") === "on",
     musicbrainz: formData.get("album-musicbrainz") === "on",
     lastfm: formData.get("album-lastfm") === "on",
+    discogs: formData.get("album-discogs") === "on",
+    itunes: formData.get("album-itunes") === "on",
+    bandcamp: formData.get("album-bandcamp") === "on",
   };
 
   await Promise.all([
@@ -82,7 +101,15 @@ const Providers = () => {
           fallback={
             <Fields
               type="artist"
-              labels={{ spotify: "Spotify", deezer: "Deezer" }}
+              labels={{
+                spotify: "Spotify",
+                deezer: "Deezer",
+                lastfm: "last.fm",
+                discogs: "Discogs",
+                wikidata: "Wikidata",
+                itunes: "iTunes",
+                bandcamp: "Bandcamp",
+              }}
             />
           }
         >
@@ -98,6 +125,9 @@ const Providers = () => {
                 deezer: "Deezer",
                 musicbrainz: "MusicBrainz",
                 lastfm: "last.fm",
+                discogs: "Discogs",
+                itunes: "iTunes",
+                bandcamp: "Bandcamp",
               }}
             />
           }
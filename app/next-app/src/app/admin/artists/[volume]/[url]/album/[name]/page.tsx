@@ -225,6 +225,27 @@ export default async function AlbumCorrection({ params, searchParams }: Props) {
             albumSearch={albumName}
             artistSearch={artistName}
           />
+          <ProviderInformation
+            provider="discogs"
+            label="Discogs"
+            params={params}
+            albumSearch={albumName}
+            artistSearch={artistName}
+          />
+          <ProviderInformation
+            provider="itunes"
+            label="iTunes"
+            params={params}
+            albumSearch={albumName}
+            artistSearch={artistName}
+          />
+          <ProviderInformation
+            provider="bandcamp"
+            label="Bandcamp"
+            params={params}
+            albumSearch={albumName}
+            artistSearch={artistName}
+          />
         </form>
       </div>
     </main>
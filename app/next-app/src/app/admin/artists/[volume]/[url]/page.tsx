@@ -179,6 +179,36 @@ export default async function ArtistCorrect({ params, searchParams }: Props) {
             params={params}
             searchValue={searchValue}
           />
+          <ProviderInformation
+            provider="lastfm"
+            label="last.fm"
+            params={params}
+            searchValue={searchValue}
+          />
+          <ProviderInformation
+            provider="discogs"
+            label="Discogs"
+            params={params}
+            searchValue={searchValue}
+          />
+          <ProviderInformation
+            provider="itunes"
+            label="iTunes"
+            params={params}
+            searchValue={searchValue}
+          />
+          <ProviderInformation
+            provider="bandcamp"
+            label="Bandcamp"
+            params={params}
+            searchValue={searchValue}
+          />
+          <ProviderInformation
+            provider="wikidata"
+            label="Wikidata"
+            params={params}
+            searchValue={searchValue}
+          />
         </form>
       </div>
     </main>
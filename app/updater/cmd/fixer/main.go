@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"os"
 	"os/signal"
 	"strings"
@@ -11,6 +13,10 @@ import (
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/waelbendhia/scruffy/app/updater/database"
+	"github.com/waelbendhia/scruffy/app/updater/logging"
+	"github.com/waelbendhia/scruffy/app/updater/scraper"
+	"github.com/waelbendhia/scruffy/app/updater/snapshot"
+	"github.com/waelbendhia/scruffy/app/updater/updater"
 	"go.uber.org/zap"
 )
 
@@ -25,24 +31,8 @@ func signalContext() context.Context {
 	return ctx
 }
 
-func main() {
-	ctx := signalContext()
-
-	var logger *zap.Logger
-	if os.Getenv("ENV") == "production" {
-		logger, _ = zap.NewProduction()
-	} else {
-		logger, _ = zap.NewDevelopment()
-	}
-	defer logger.Sync()
-
-	db, err := sql.Open("sqlite3", os.Getenv("DATABASE_PATH"))
-	if err != nil {
-		logger.With(zap.Error(err)).Fatal("could not open db")
-	}
-
-	defer db.Close()
-
+// stripInvalidUTF8 removes the invalid UTF-8 sequences from the stored bios.
+func stripInvalidUTF8(ctx context.Context, logger *zap.Logger, db *sql.DB) {
 	var txClosed bool
 
 	tx, err := db.BeginTx(ctx, &sql.TxOptions{})
@@ -89,3 +79,100 @@ func main() {
 
 	txClosed = true
 }
+
+// redecodeBios reads the bios of the artists whose page is not encoded in
+// UTF-8 again, now that pages are decoded to UTF-8 before being parsed.
+func redecodeBios(ctx context.Context, logger *zap.Logger, db *sql.DB, refetch bool) {
+	q := database.New(db)
+	u := updater.NewUpdater(db)
+
+	bios, err := q.SelectAllBios(ctx)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("could not read bios")
+		return
+	}
+
+	updated := 0
+	for _, a := range bios {
+		log := logger.With(zap.String("url", a.Url))
+
+		page, err := u.LoadPage(ctx, a.Url, refetch)
+		if errors.Is(err, updater.ErrNoSnapshot) || errors.Is(err, snapshot.ErrSnapshotNotFound) {
+			log.Debug("skipping artist without snapshot")
+			continue
+		} else if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return
+			}
+			log.With(zap.Error(err)).Error("could not load page")
+			continue
+		}
+
+		if page.Charset == "utf-8" {
+			continue
+		}
+
+		artist, err := scraper.ReadArtistFromPage(ctx, a.Url, page.Doc)
+		if err != nil {
+			log.With(zap.Error(err)).Error("could not read artist")
+			continue
+		}
+
+		if artist.Bio == a.Bio.String {
+			continue
+		}
+
+		if err := q.UpdateBio(ctx, database.UpdateBioParams{
+			Url: a.Url,
+			Bio: sql.NullString{Valid: artist.Bio != "", String: artist.Bio},
+			Biomarkdown: sql.NullString{
+				Valid:  artist.BioMarkdown != "",
+				String: artist.BioMarkdown,
+			},
+		}); err != nil {
+			log.With(zap.Error(err)).Error("could not update bio")
+			continue
+		}
+
+		updated++
+	}
+
+	logger.With(zap.Int("count", updated)).Info("redecoded bios")
+}
+
+func main() {
+	redecode := flag.Bool(
+		"redecode", false,
+		"read the bios of artists whose page is not UTF-8 again from their snapshot",
+	)
+	refetch := flag.Bool(
+		"refetch", false,
+		"with -redecode, fetch the pages that have no snapshot",
+	)
+	flag.Parse()
+
+	ctx := signalContext()
+
+	var logger *zap.Logger
+	if os.Getenv("ENV") == "production" {
+		logger, _ = zap.NewProduction()
+	} else {
+		logger, _ = zap.NewDevelopment()
+	}
+	defer logger.Sync()
+	ctx = logging.SetLogger(ctx, logger)
+
+	db, err := sql.Open("sqlite3", os.Getenv("DATABASE_PATH"))
+	if err != nil {
+		logger.With(zap.Error(err)).Fatal("could not open db")
+	}
+
+	defer db.Close()
+
+	if *redecode {
+		redecodeBios(ctx, logger, db, *refetch)
+		return
+	}
+
+	stripInvalidUTF8(ctx, logger, db)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"os/signal"
+	"syscall"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/waelbendhia/scruffy/app/updater/logging"
+	"github.com/waelbendhia/scruffy/app/updater/scraper"
+	"github.com/waelbendhia/scruffy/app/updater/updater"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+func signalContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		cancel()
+	}()
+	return ctx
+}
+
+// reparse runs the page readers over the stored page snapshots and writes the
+// results to the database without fetching anything. Pages can be filtered by
+// passing path prefixes as arguments, e.g. `reparse /vol6/ /jazz/`.
+func main() {
+	ctx := signalContext()
+
+	var logger *zap.Logger
+	if os.Getenv("ENV") == "production" {
+		logger, _ = zap.NewProduction()
+	} else {
+		logger, _ = zap.NewDevelopment()
+	}
+	defer logger.Sync()
+	ctx = logging.SetLogger(ctx, logger)
+
+	registry := scraper.DefaultRegistry()
+	if registryPath := os.Getenv("PAGE_REGISTRY_PATH"); registryPath != "" {
+		var err error
+		registry, err = scraper.LoadRegistry(registryPath)
+		if err != nil {
+			logger.With(
+				zap.String("page-registry-path", registryPath),
+				zap.Error(err),
+			).Fatal("could not load page registry")
+		}
+	}
+
+	db, err := sql.Open("sqlite3", os.Getenv("DATABASE_PATH"))
+	if err != nil {
+		logger.With(zap.Error(err)).Fatal("could not open db")
+	}
+
+	defer db.Close()
+
+	u := updater.NewUpdater(db, updater.WithPageRegistry(registry))
+
+	runID, err := u.StartRun(ctx, updater.RunKindReparse)
+	if err != nil {
+		logger.With(zap.Error(err)).Error("could not start run")
+	}
+
+	artists, albums, rankings := u.ReparseSnapshots(ctx, os.Args[1:]...)
+	finalArtists := u.InsertRelatedArtists(ctx, u.InsertArtists(ctx, runID, artists))
+	finalAlbums := u.InsertAlbums(ctx, runID, albums)
+
+	var g errgroup.Group
+	g.Go(func() error {
+		count := 0
+		for range finalArtists {
+			count++
+		}
+		logger.With(zap.Int("count", count)).Info("reparsed artists")
+		return nil
+	})
+	g.Go(func() error {
+		count := 0
+		for range finalAlbums {
+			count++
+		}
+		logger.With(zap.Int("count", count)).Info("reparsed albums")
+		return nil
+	})
+	var readRankings []scraper.Ranking
+	g.Go(func() error {
+		for r := range rankings {
+			readRankings = append(readRankings, r)
+		}
+		return nil
+	})
+
+	g.Wait()
+
+	// Rankings link to the artists and albums so they go in last.
+	in := make(chan scraper.Ranking, len(readRankings))
+	for _, r := range readRankings {
+		in <- r
+	}
+	close(in)
+
+	count := 0
+	for range u.InsertRankings(ctx, in) {
+		count++
+	}
+	logger.With(zap.Int("count", count)).Info("reparsed rankings")
+
+	if err := u.SaveContentHashes(ctx); err != nil {
+		logger.With(zap.Error(err)).Error("could not save content hashes")
+	}
+
+	if runID != 0 {
+		if err := u.FinishRun(context.WithoutCancel(ctx), runID); err != nil {
+			logger.With(zap.Error(err)).Error("could not finish run")
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -22,6 +23,7 @@ import (
 	"github.com/waelbendhia/scruffy/app/updater/provider"
 	"github.com/waelbendhia/scruffy/app/updater/scraper"
 	"github.com/waelbendhia/scruffy/app/updater/server"
+	"github.com/waelbendhia/scruffy/app/updater/snapshot"
 	"github.com/waelbendhia/scruffy/app/updater/status"
 	"github.com/waelbendhia/scruffy/app/updater/updater"
 	"go.uber.org/zap"
@@ -47,18 +49,33 @@ func initUpdater(
 	dp *provider.DeezerProvider,
 	mbp *provider.MusicBrainzProvider,
 	lfmp *provider.LastFMProvider,
+	dgp *provider.DiscogsProvider,
+	wdp *provider.WikidataProvider,
+	ip *provider.ITunesProvider,
+	bp *provider.BandcampProvider,
 	su *status.StatusUpdater,
+	registry *scraper.Registry,
 ) *updater.Updater {
 	opts := []updater.UpdaterOption{
 		updater.WithConcurrency(max(runtime.NumCPU(), 4)),
+		updater.WithPageRegistry(registry),
 		updater.WithErrorHook(func(err error) { su.AddError(ctx, err) }),
 		updater.WithPageHook(func(*scraper.ScruffyPage) { su.IncrementPages(ctx) }),
+		updater.WithQuarantineHook(func(page, reason string) { su.AddQuarantined(ctx, page, reason) }),
 		updater.AddArtistProvider(1, sp),
 		updater.AddArtistProvider(1, dp),
+		updater.AddArtistProvider(1, lfmp),
+		updater.AddArtistProvider(1, dgp),
+		updater.AddArtistProvider(2, wdp),
+		updater.AddArtistProvider(1, ip),
+		updater.AddArtistProvider(1, bp),
 		updater.AddAlbumProvider(9, sp),
 		updater.AddAlbumProvider(8, dp),
 		updater.AddAlbumProvider(10, mbp),
-		updater.AddAlbumProvider(0, lfmp),
+		updater.AddAlbumProvider(5, lfmp),
+		updater.AddAlbumProvider(8, dgp),
+		updater.AddAlbumProvider(6, ip),
+		updater.AddAlbumProvider(4, bp),
 	}
 
 	for _, p := range strings.Split(os.Getenv("ARTIST_PROVIDERS"), ",") {
@@ -68,6 +85,16 @@ func initUpdater(
 			sp.ArtistEnable()
 		case "deezer":
 			dp.ArtistEnable()
+		case "lastfm":
+			lfmp.ArtistEnable()
+		case "discogs":
+			dgp.ArtistEnable()
+		case "wikidata":
+			wdp.ArtistEnable()
+		case "itunes":
+			ip.ArtistEnable()
+		case "bandcamp":
+			bp.ArtistEnable()
 		}
 	}
 
@@ -81,10 +108,92 @@ func initUpdater(
 		case "musicbrainz":
 			mbp.AlbumEnable()
 		case "lastfm":
-			// TODO: implement LastFM provider
-			logging.GetLogger(ctx).Warn("last.fm provider not implemented")
+			lfmp.AlbumEnable()
+		case "discogs":
+			dgp.AlbumEnable()
+		case "itunes":
+			ip.AlbumEnable()
+		case "bandcamp":
+			bp.AlbumEnable()
+		}
+	}
+
+	if (lfmp.AlbumEnabled() || lfmp.ArtistEnabled()) && os.Getenv("LAST_FM_API_KEY") == "" {
+		logging.GetLogger(ctx).Warn("last.fm provider enabled without an API key")
+	}
+
+	if (dgp.AlbumEnabled() || dgp.ArtistEnabled()) && os.Getenv("DISCOGS_TOKEN") == "" {
+		logging.GetLogger(ctx).Warn("discogs provider enabled without a token")
+	}
+
+	if depth := os.Getenv("DISCOVERY_MAX_DEPTH"); depth != "" {
+		maxDepth, err := strconv.Atoi(depth)
+		if err != nil {
+			logging.GetLogger(ctx).With(
+				zap.String("discovery-max-depth", depth),
+				zap.Error(err),
+			).Fatal("could not parse discovery max depth")
+		}
+
+		maxPages := 0
+		if pages := os.Getenv("DISCOVERY_MAX_PAGES"); pages != "" {
+			maxPages, err = strconv.Atoi(pages)
+			if err != nil {
+				logging.GetLogger(ctx).With(
+					zap.String("discovery-max-pages", pages),
+					zap.Error(err),
+				).Fatal("could not parse discovery max pages")
+			}
+		}
+
+		opts = append(opts, updater.WithArtistDiscovery(maxDepth, maxPages))
+	}
+
+	retention := snapshot.DefaultRetentionPolicy()
+	if versions := os.Getenv("SNAPSHOT_KEEP_VERSIONS"); versions != "" {
+		var err error
+		retention.Versions, err = strconv.Atoi(versions)
+		if err != nil {
+			logging.GetLogger(ctx).With(
+				zap.String("snapshot-keep-versions", versions),
+				zap.Error(err),
+			).Fatal("could not parse snapshot versions to keep")
+		}
+	}
+	if maxAge := os.Getenv("SNAPSHOT_MAX_AGE"); maxAge != "" {
+		var err error
+		retention.MaxAge, err = time.ParseDuration(maxAge)
+		if err != nil {
+			logging.GetLogger(ctx).With(
+				zap.String("snapshot-max-age", maxAge),
+				zap.Error(err),
+			).Fatal("could not parse snapshot max age")
+		}
+	}
+	opts = append(opts, updater.WithSnapshotRetention(retention))
+
+	guard := updater.DefaultParseGuard()
+	if ratio := os.Getenv("PARSE_GUARD_MIN_RATIO"); ratio != "" {
+		var err error
+		guard.MinRatio, err = strconv.ParseFloat(ratio, 64)
+		if err != nil {
+			logging.GetLogger(ctx).With(
+				zap.String("parse-guard-min-ratio", ratio),
+				zap.Error(err),
+			).Fatal("could not parse parse guard min ratio")
+		}
+	}
+	if maxQuarantined := os.Getenv("PARSE_GUARD_MAX_QUARANTINED"); maxQuarantined != "" {
+		var err error
+		guard.MaxQuarantined, err = strconv.Atoi(maxQuarantined)
+		if err != nil {
+			logging.GetLogger(ctx).With(
+				zap.String("parse-guard-max-quarantined", maxQuarantined),
+				zap.Error(err),
+			).Fatal("could not parse parse guard max quarantined pages")
 		}
 	}
+	opts = append(opts, updater.WithParseGuard(guard))
 
 	return updater.NewUpdater(db, opts...)
 }
@@ -100,6 +209,7 @@ type updateRunner struct {
 func (u *updateRunner) runUpdatesForever(
 	ctx context.Context,
 	startSignal <-chan struct{},
+	reparseSignal <-chan []string,
 	onStart func(context.Context),
 	onEnd func(context.Context),
 	onArtist func(context.Context),
@@ -109,47 +219,47 @@ func (u *updateRunner) runUpdatesForever(
 		onStart(ctx)
 		u.runUpdate(ctx, onArtist, onAlbum)
 		onEnd(ctx)
-		select {
-		case <-time.After(u.updateInterval):
-		case <-startSignal:
-		case <-ctx.Done():
-			return
+
+		// Reparsing does not push back the next update.
+		next := time.After(u.updateInterval)
+	wait:
+		for {
+			select {
+			case <-next:
+				break wait
+			case <-startSignal:
+				break wait
+			case prefixes := <-reparseSignal:
+				onStart(ctx)
+				u.runReparse(ctx, prefixes, onArtist, onAlbum)
+				onEnd(ctx)
+			case <-ctx.Done():
+				return
+			}
 		}
 	}
 }
 
-func (u *updateRunner) runUpdate(
+func (u *updateRunner) insertAll(
 	ctx context.Context,
+	runID int64,
+	artists <-chan updater.ArtistWithImage,
+	albums <-chan updater.AlbumWithImage,
+	rankings <-chan scraper.Ranking,
 	onArtist func(context.Context),
 	onAlbum func(context.Context),
 ) {
-	ctx, cancel := context.WithCancel(ctx)
-
-	u.cancelLock.Lock()
-	u.cancel = cancel
-	u.cancelLock.Unlock()
-
 	logger := logging.GetLogger(ctx)
 
-	start := time.Now()
-
-	logger.Info("starting update")
-	defer func() {
-		logger.With(zap.Duration("duration", time.Since(start))).Info("update finished")
-	}()
+	finalArtists := u.InsertRelatedArtists(ctx, u.InsertArtists(ctx, runID, artists))
+	finalAlbums := u.InsertAlbums(ctx, runID, albums)
 
-	ars, als := u.GetAllArtistsAndRatings(ctx, u.filterUnchanged)
-	artistsWithImages, albums := u.ProcessArtists(ctx, u.filterUnchanged, ars)
-	finalArtists := u.InsertArtists(ctx, artistsWithImages)
-	processedAlbums := u.ProcessAlbums(ctx, u.filterUnchanged, als, albums)
-	finalAlbums := u.InsertAlbums(ctx, processedAlbums)
-
-	g, ctx := errgroup.WithContext(ctx)
+	g, gctx := errgroup.WithContext(ctx)
 
 	g.Go(func() error {
 		count := 0
 		for range finalArtists {
-			onArtist(ctx)
+			onArtist(gctx)
 			count++
 		}
 		logger.With(zap.Int("count", count)).Info("inserted artists")
@@ -158,21 +268,150 @@ func (u *updateRunner) runUpdate(
 	g.Go(func() error {
 		count := 0
 		for range finalAlbums {
-			onAlbum(ctx)
+			onAlbum(gctx)
 			count++
 		}
 		logger.With(zap.Int("count", count)).Info("inserted albums")
 		return nil
 	})
+	// Rankings link to the artists and albums, they are held back until
+	// those are inserted.
+	var readRankings []scraper.Ranking
+	g.Go(func() error {
+		for r := range rankings {
+			readRankings = append(readRankings, r)
+		}
+		return nil
+	})
 
 	if err := g.Wait(); err != nil {
 		logger.With(zap.Error(err)).Error("processing failed")
 	}
+
+	in := make(chan scraper.Ranking, len(readRankings))
+	for _, r := range readRankings {
+		in <- r
+	}
+	close(in)
+
+	count := 0
+	for range u.InsertRankings(ctx, in) {
+		count++
+	}
+	logger.With(zap.Int("count", count)).Info("inserted rankings")
+
+	if err := u.SaveContentHashes(ctx); err != nil {
+		logger.With(zap.Error(err)).Error("could not save content hashes")
+	}
+}
+
+// startRun records the start of a run, changes are recorded outside of any
+// run when it cannot be.
+func (u *updateRunner) startRun(ctx context.Context, kind string) int64 {
+	runID, err := u.StartRun(ctx, kind)
+	if err != nil {
+		logging.GetLogger(ctx).With(zap.Error(err)).Error("could not start run")
+	}
+	return runID
+}
+
+func (u *updateRunner) finishRun(ctx context.Context, runID int64) {
+	if runID == 0 {
+		return
+	}
+	// The run is finished even when it was cancelled.
+	if err := u.FinishRun(context.WithoutCancel(ctx), runID); err != nil {
+		logging.GetLogger(ctx).With(zap.Error(err)).Error("could not finish run")
+	}
+}
+
+func (u *updateRunner) runReparse(
+	ctx context.Context,
+	prefixes []string,
+	onArtist func(context.Context),
+	onAlbum func(context.Context),
+) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	u.cancelLock.Lock()
+	u.cancel = cancel
+	u.cancelLock.Unlock()
+
+	logger := logging.GetLogger(ctx).With(zap.Strings("prefixes", prefixes))
+
+	start := time.Now()
+
+	logger.Info("starting reparse")
+	defer func() {
+		logger.With(zap.Duration("duration", time.Since(start))).Info("reparse finished")
+	}()
+
+	runID := u.startRun(ctx, updater.RunKindReparse)
+	defer u.finishRun(ctx, runID)
+
+	ctx, stop := u.GuardRun(ctx)
+	defer stop()
+
+	artists, albums, rankings := u.ReparseSnapshots(ctx, prefixes...)
+	u.insertAll(ctx, runID, artists, albums, rankings, onArtist, onAlbum)
+
+	if cause := context.Cause(ctx); errors.Is(cause, updater.ErrTooManyQuarantined) {
+		logger.With(zap.Error(cause)).Error("reparse aborted")
+	}
+}
+
+func (u *updateRunner) runUpdate(
+	ctx context.Context,
+	onArtist func(context.Context),
+	onAlbum func(context.Context),
+) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	u.cancelLock.Lock()
+	u.cancel = cancel
+	u.cancelLock.Unlock()
+
+	logger := logging.GetLogger(ctx)
+
+	start := time.Now()
+
+	logger.Info("starting update")
+	defer func() {
+		logger.With(zap.Duration("duration", time.Since(start))).Info("update finished")
+	}()
+
+	runID := u.startRun(ctx, updater.RunKindUpdate)
+	defer u.finishRun(ctx, runID)
+
+	ctx, stop := u.GuardRun(ctx)
+	defer stop()
+
+	ars, als, rankings := u.GetAllArtistsAndRatings(ctx, u.filterUnchanged)
+	artistsWithImages, albums := u.ProcessArtists(ctx, u.filterUnchanged, ars)
+	processedAlbums := u.ProcessAlbums(ctx, u.filterUnchanged, als, albums)
+	u.insertAll(ctx, runID, artistsWithImages, processedAlbums, rankings, onArtist, onAlbum)
+
+	if cause := context.Cause(ctx); errors.Is(cause, updater.ErrTooManyQuarantined) {
+		logger.With(zap.Error(cause)).Error("update aborted")
+	}
+
+	// Only a full update that ran to completion saw everything that is still
+	// on the site.
+	if !u.filterUnchanged && ctx.Err() == nil {
+		if err := u.Reconcile(ctx, start); err != nil {
+			logger.With(zap.Error(err)).Error("could not reconcile removals")
+		}
+	}
+
+	if err := u.PruneSnapshots(ctx); err != nil {
+		logger.With(zap.Error(err)).Error("could not prune page snapshots")
+	}
 }
 
 type runner struct {
 	*updateRunner
-	startCh chan<- struct{}
+	startCh   chan<- struct{}
+	reparseCh chan<- []string
 }
 
 func (r *runner) StopUpdate(context.Context) error {
@@ -191,6 +430,15 @@ func (r *runner) StartUpdate(ctx context.Context) error {
 	return nil
 }
 
+func (r *runner) Reparse(ctx context.Context, prefixes []string) error {
+	select {
+	case r.reparseCh <- prefixes:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
 func main() {
 	ctx := signalContext()
 
@@ -228,6 +476,18 @@ func main() {
 
 	su := status.NewStatusUpdater(status.WithRevalidator(revalidator))
 
+	registry := scraper.DefaultRegistry()
+	if registryPath := os.Getenv("PAGE_REGISTRY_PATH"); registryPath != "" {
+		var err error
+		registry, err = scraper.LoadRegistry(registryPath)
+		if err != nil {
+			logger.With(
+				zap.String("page-registry-path", registryPath),
+				zap.Error(err),
+			).Fatal("could not load page registry")
+		}
+	}
+
 	db, err := sql.Open("sqlite3", os.Getenv("DATABASE_PATH"))
 	if err != nil {
 		logger.With(zap.Error(err)).Fatal("could not open db")
@@ -247,21 +507,28 @@ func main() {
 	)
 	dp := provider.NewDeezerProvider()
 	mbp := provider.NewMusicBrainzProvider()
+	lfmp := provider.NewLastFMProvider()
+	dgp := provider.NewDiscogsProvider()
+	wdp := provider.NewWikidataProvider()
+	ip := provider.NewITunesProvider()
+	bp := provider.NewBandcampProvider()
 
 	ur := updateRunner{
-		Updater:        initUpdater(ctx, db, sp, dp, mbp, &provider.LastFMProvider{}, su),
+		Updater:        initUpdater(ctx, db, sp, dp, mbp, lfmp, dgp, wdp, ip, bp, su, registry),
 		updateInterval: updateInterval,
 		// TODO: make this configurable at runtime.
 		filterUnchanged: os.Getenv("FILTER_UNCHANGED") == "true",
 	}
 
 	startCh := make(chan struct{}, 1)
+	reparseCh := make(chan []string, 1)
 
 	g, ctx := errgroup.WithContext(ctx)
 	g.Go(func() error {
 		ur.runUpdatesForever(
 			ctx,
 			startCh,
+			reparseCh,
 			func(ctx context.Context) { su.StartUpdate(ctx) },
 			func(ctx context.Context) { su.EndUpdate(ctx) },
 			func(ctx context.Context) { su.IncrementArtists(ctx) },
@@ -276,14 +543,22 @@ func main() {
 
 		s := server.New(
 			db,
-			&runner{updateRunner: &ur, startCh: startCh},
+			&runner{updateRunner: &ur, startCh: startCh, reparseCh: reparseCh},
 			su,
 			server.AddArtistProviders(sp),
 			server.AddArtistProviders(dp),
+			server.AddArtistProviders(lfmp),
+			server.AddArtistProviders(dgp),
+			server.AddArtistProviders(wdp),
+			server.AddArtistProviders(ip),
+			server.AddArtistProviders(bp),
 			server.AddAlbumProviders(sp),
 			server.AddAlbumProviders(dp),
 			server.AddAlbumProviders(mbp),
-			server.AddAlbumProviders(&provider.LastFMProvider{}),
+			server.AddAlbumProviders(lfmp),
+			server.AddAlbumProviders(dgp),
+			server.AddAlbumProviders(ip),
+			server.AddAlbumProviders(bp),
 		)
 
 		s.Routing(engine)
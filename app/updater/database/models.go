@@ -16,6 +16,18 @@ type Album struct {
 	ArtistUrl string
 	ImageUrl  sql.NullString
 	PageURL   string
+	Position  sql.NullInt64
+	Kind      sql.NullString
+	Credits   sql.NullString
+	SeenAt    sql.NullTime
+	DeletedAt sql.NullTime
+}
+
+type AlbumAlias struct {
+	ArtistUrl  string
+	Alias      string
+	Normalized string
+	Name       string
 }
 
 type Artist struct {
@@ -24,6 +36,97 @@ type Artist struct {
 	Bio          sql.NullString
 	ImageUrl     sql.NullString
 	LastModified time.Time
+	BioMarkdown  sql.NullString
+	BioLanguage  string
+	NotFoundAt   sql.NullTime
+	DeletedAt    sql.NullTime
+}
+
+type ArtistAlias struct {
+	Alias     string
+	Url       string
+	CreatedAt time.Time
+}
+
+type ArtistExternalID struct {
+	ArtistUrl  string
+	Service    string
+	ExternalId string
+}
+
+type BioRevision struct {
+	ID          int64
+	ArtistUrl   string
+	Bio         string
+	BioMarkdown sql.NullString
+	RunId       sql.NullInt64
+	CreatedAt   time.Time
+}
+
+type PageDiagnostics struct {
+	PageURL      string
+	NameStrategy sql.NullString
+	BioStrategy  sql.NullString
+	AlbumMisses  int64
+	SkippedRows  int64
+	Diagnostics  string
+	RecordedAt   time.Time
+}
+
+type PageParse struct {
+	PageURL   string
+	Albums    sql.NullInt64
+	BioLength sql.NullInt64
+	Links     sql.NullInt64
+	ParsedAt  time.Time
+}
+
+type PageQuarantine struct {
+	PageURL       string
+	Reason        string
+	QuarantinedAt time.Time
+}
+
+type PageSnapshot struct {
+	Hash      string
+	Content   []byte
+	CreatedAt time.Time
+}
+
+type PageVersion struct {
+	PageURL   string
+	Hash      string
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+type Ranking struct {
+	PageURL      string
+	Title        string
+	LastModified time.Time
+}
+
+type RankingEntry struct {
+	RankingUrl string
+	Position   int64
+	Rank       int64
+	ArtistName string
+	AlbumTitle string
+	Year       sql.NullInt64
+	ArtistUrl  sql.NullString
+	AlbumName  sql.NullString
+	ArtistLink sql.NullString
+}
+
+type RatingHistory struct {
+	ID        int64
+	ArtistUrl string
+	AlbumName string
+	OldRating float64
+	NewRating float64
+	PageURL   string
+	RunId     sql.NullInt64
+	ChangedAt time.Time
 }
 
 type RelatedArtists struct {
@@ -32,7 +135,18 @@ type RelatedArtists struct {
 }
 
 type UpdateHistory struct {
-	CheckedOn time.Time
-	Hash      string
-	PageURL   string
+	CheckedOn    time.Time
+	Hash         string
+	PageURL      string
+	SnapshotHash sql.NullString
+	Etag         sql.NullString
+	LastModified sql.NullTime
+	ContentHash  sql.NullString
+}
+
+type UpdateRun struct {
+	ID        int64
+	Kind      string
+	StartedAt time.Time
+	EndedAt   sql.NullTime
 }
@@ -6,6 +6,54 @@ FROM
 WHERE
   "pageURL" = @pageURL;
 
+-- name: ListSnapshotPages :many
+SELECT
+  "pageURL",
+  "snapshotHash"
+FROM
+  "UpdateHistory"
+WHERE
+  "snapshotHash" IS NOT NULL
+ORDER BY
+  "pageURL";
+
+-- name: GetArtist :one
+SELECT
+  *
+FROM
+  "Artist"
+WHERE
+  "url" = @url;
+
+-- name: GetAlbum :one
+SELECT
+  *
+FROM
+  "Album"
+WHERE
+  "artistUrl" = @artistUrl
+  AND "name" = @name;
+
+-- name: GetAlbumAliasName :one
+SELECT
+  "name"
+FROM
+  "AlbumAlias"
+WHERE
+  "artistUrl" = @artistUrl
+  AND "normalized" = @normalized
+LIMIT 1;
+
+-- name: ListArtistAlbumNames :many
+SELECT
+  "name"
+FROM
+  "Album"
+WHERE
+  "artistUrl" = @artistUrl
+ORDER BY
+  "name";
+
 -- name: CheckPageExists :one
 SELECT
   EXISTS (
@@ -17,31 +65,128 @@ SELECT
       "hash" = @hash);
 
 -- name: UpsertUpdateHistory :one
-INSERT INTO "UpdateHistory" ("checkedOn", "hash", "pageURL")
-  VALUES (@checkedOn, @hash, @pageURL)
+INSERT INTO "UpdateHistory" ("checkedOn", "hash", "pageURL", "snapshotHash", "etag", "lastModified")
+  VALUES (@checkedOn, @hash, @pageURL, @snapshotHash, @etag, @lastModified)
 ON CONFLICT ("pageURL")
   DO UPDATE SET
-    "hash" = excluded."hash", "checkedOn" = excluded."checkedOn"
+    "hash" = excluded."hash", "checkedOn" = excluded."checkedOn",
+      "snapshotHash" = excluded."snapshotHash", "etag" = excluded."etag",
+      "lastModified" = excluded."lastModified"
   WHERE
     excluded."hash" != "UpdateHistory"."hash"
   RETURNING
-    "checkedOn", "hash", "pageURL";
+    "checkedOn", "hash", "pageURL", "snapshotHash", "etag", "lastModified", "contentHash";
+
+-- name: RefreshUpdateHistory :exec
+UPDATE
+  "UpdateHistory"
+SET
+  "snapshotHash" = COALESCE(sqlc.narg(snapshotHash), "snapshotHash"),
+  "etag" = @etag,
+  "lastModified" = @lastModified
+WHERE
+  "pageURL" = @pageURL
+  AND "hash" = @hash;
+
+-- name: InsertPageSnapshot :exec
+INSERT INTO "PageSnapshot" ("hash", "content", "createdAt")
+  VALUES (@hash, @content, @createdAt)
+ON CONFLICT ("hash")
+  DO NOTHING;
+
+-- name: GetPageSnapshot :one
+SELECT
+  *
+FROM
+  "PageSnapshot"
+WHERE
+  "hash" = @hash;
+
+-- name: UpsertPageVersion :exec
+INSERT INTO "PageVersion" ("pageURL", "hash", "firstSeen", "lastSeen")
+  VALUES (@pageURL, @hash, @seenOn, @seenOn)
+ON CONFLICT ("pageURL", "hash")
+  DO UPDATE SET
+    "lastSeen" = excluded."lastSeen";
+
+-- name: ListPageVersions :many
+SELECT
+  *
+FROM
+  "PageVersion"
+WHERE
+  "pageURL" = @pageURL
+ORDER BY
+  "lastSeen" DESC;
+
+-- name: PrunePageVersions :execrows
+DELETE FROM "PageVersion" AS v
+WHERE NOT EXISTS (
+    SELECT
+      1
+    FROM
+      "UpdateHistory" h
+    WHERE
+      h."pageURL" = v."pageURL"
+      AND h."snapshotHash" = v."hash")
+  AND (v."lastSeen" < @cutoff
+    OR (
+      SELECT
+        COUNT(*)
+      FROM
+        "PageVersion" n
+      WHERE
+        n."pageURL" = v."pageURL"
+        AND n."lastSeen" > v."lastSeen") >= CAST(@keep AS INTEGER));
+
+-- name: PrunePageSnapshots :execrows
+DELETE FROM "PageSnapshot"
+WHERE "hash" NOT IN (
+    SELECT
+      "hash"
+    FROM
+      "PageVersion")
+  AND "hash" NOT IN (
+    SELECT
+      "snapshotHash"
+    FROM
+      "UpdateHistory"
+    WHERE
+      "snapshotHash" IS NOT NULL);
 
 -- name: UpsertAlbum :exec
-INSERT INTO "Album" ("name", "year", "rating", "artistUrl", "imageUrl", "pageURL")
-  VALUES (@name, @year, @rating, @artistUrl, @imageUrl, @pageURL)
+INSERT INTO "Album" ("name", "year", "rating", "artistUrl", "imageUrl", "pageURL", "position", "kind", "credits", "seenAt")
+  VALUES (@name, @year, @rating, @artistUrl, @imageUrl, @pageURL, @position, @kind, @credits, @seenAt)
 ON CONFLICT ("artistUrl", "name")
   DO UPDATE SET
     "year" = excluded."year", "rating" = excluded."rating", "imageUrl" = excluded."imageUrl",
-      "pageURL" = excluded."pageURL";
+      "pageURL" = excluded."pageURL", "seenAt" = excluded."seenAt", "deletedAt" = NULL,
+      "position" = COALESCE(excluded."position", "position"),
+      "kind" = CASE WHEN excluded."position" IS NULL THEN "kind" ELSE excluded."kind" END,
+      "credits" = CASE WHEN excluded."position" IS NULL THEN "credits" ELSE excluded."credits" END;
+
+-- name: UpsertAlbumAlias :exec
+INSERT INTO "AlbumAlias" ("artistUrl", "alias", "normalized", "name")
+  VALUES (@artistUrl, @alias, @normalized, @name)
+ON CONFLICT ("artistUrl", "alias")
+  DO UPDATE SET
+    "normalized" = excluded."normalized", "name" = excluded."name";
 
 -- name: UpsertArtist :exec
-INSERT INTO "Artist" ("url", "name", "bio", "imageUrl", "lastModified")
-  VALUES (@url, @name, @bio, @imageUrl, DATETIME('now'))
+INSERT INTO "Artist" ("url", "name", "bio", "bioMarkdown", "bioLanguage", "imageUrl", "lastModified")
+  VALUES (@url, @name, @bio, @bioMarkdown, @bioLanguage, @imageUrl, DATETIME('now'))
 ON CONFLICT ("url")
   DO UPDATE SET
-    "name" = excluded."name", "bio" = excluded."bio", "imageUrl" = excluded."imageUrl",
-      "lastModified" = excluded."lastModified";
+    "name" = excluded."name", "bio" = excluded."bio", "bioMarkdown" = excluded."bioMarkdown",
+      "bioLanguage" = excluded."bioLanguage", "imageUrl" = excluded."imageUrl",
+      "lastModified" = excluded."lastModified", "notFoundAt" = NULL, "deletedAt" = NULL;
+
+-- name: UpsertArtistExternalID :exec
+INSERT INTO "ArtistExternalID" ("artistUrl", "service", "externalId")
+  VALUES (@artistUrl, @service, @externalId)
+ON CONFLICT ("artistUrl", "service")
+  DO UPDATE SET
+    "externalId" = excluded."externalId";
 
 -- name: UpdateArtistNameAndImage :one
 UPDATE
@@ -56,7 +201,11 @@ RETURNING
   "name",
   "bio",
   "imageUrl",
-  "lastModified";
+  "lastModified",
+  "bioMarkdown",
+  "bioLanguage",
+  "notFoundAt",
+  "deletedAt";
 
 -- name: UpdateAlbum :one
 UPDATE
@@ -74,7 +223,12 @@ RETURNING
   "rating",
   "artistUrl",
   "imageUrl",
-  "pageURL";
+  "pageURL",
+  "position",
+  "kind",
+  "credits",
+  "seenAt",
+  "deletedAt";
 
 -- name: SelectAllBios :many
 SELECT
@@ -87,6 +241,491 @@ FROM
 UPDATE
   "Artist"
 SET
-  "bio" = @bio
+  "bio" = @bio,
+  "bioMarkdown" = COALESCE(sqlc.narg(bioMarkdown), "bioMarkdown")
 WHERE
   "url" = @url;
+
+-- name: DeleteRelatedArtists :exec
+DELETE FROM "_RelatedArtists"
+WHERE "A" = @a;
+
+-- name: InsertRelatedArtist :exec
+INSERT INTO "_RelatedArtists" ("A", "B")
+SELECT
+  "a"."url",
+  "b"."url"
+FROM
+  "Artist" AS "a",
+  "Artist" AS "b"
+WHERE
+  "a"."url" = @a
+  AND "b"."url" = @b
+ON CONFLICT ("A", "B")
+  DO NOTHING;
+
+-- name: MarkArtistNotFound :exec
+UPDATE
+  "Artist"
+SET
+  "notFoundAt" = @notFoundAt
+WHERE
+  "url" = @url;
+
+-- name: TombstoneNotFoundArtists :execrows
+UPDATE
+  "Artist"
+SET
+  "deletedAt" = @deletedAt
+WHERE
+  "deletedAt" IS NULL
+  AND "notFoundAt" >= @since;
+
+-- name: TombstoneArtistAlbums :execrows
+UPDATE
+  "Album"
+SET
+  "deletedAt" = @deletedAt
+WHERE
+  "deletedAt" IS NULL
+  AND "artistUrl" IN (
+    SELECT
+      "url"
+    FROM
+      "Artist"
+    WHERE
+      "deletedAt" IS NOT NULL);
+
+-- name: ListSeenAlbumPages :many
+SELECT DISTINCT
+  "pageURL"
+FROM
+  "Album"
+WHERE
+  "seenAt" >= @since;
+
+-- name: TombstoneMissingAlbums :execrows
+UPDATE
+  "Album"
+SET
+  "deletedAt" = @deletedAt
+WHERE
+  "deletedAt" IS NULL
+  AND "pageURL" = @pageURL
+  AND ("seenAt" IS NULL
+    OR "seenAt" < @since);
+
+-- name: ListTombstonedArtists :many
+SELECT
+  "url",
+  "name",
+  "deletedAt"
+FROM
+  "Artist"
+WHERE
+  "deletedAt" IS NOT NULL
+ORDER BY
+  "deletedAt" DESC;
+
+-- name: ListTombstonedAlbums :many
+SELECT
+  "artistUrl",
+  "name",
+  "pageURL",
+  "deletedAt"
+FROM
+  "Album"
+WHERE
+  "deletedAt" IS NOT NULL
+ORDER BY
+  "deletedAt" DESC;
+
+-- name: GetArtistAlias :one
+SELECT
+  "url"
+FROM
+  "ArtistAlias"
+WHERE
+  LOWER("alias") = LOWER(@alias);
+
+-- name: GetArtistURLByKey :one
+SELECT
+  "url"
+FROM
+  "Artist"
+WHERE
+  LOWER("url") = LOWER(@url)
+LIMIT 1;
+
+-- name: FindMovedArtist :one
+SELECT
+  "url"
+FROM
+  "Artist"
+WHERE
+  "name" = @name
+  AND "bio" = @bio
+  AND "url" != @url
+ORDER BY
+  "lastModified" DESC
+LIMIT 1;
+
+-- name: UpsertArtistAlias :exec
+INSERT INTO "ArtistAlias" ("alias", "url")
+  VALUES (@alias, @url)
+ON CONFLICT ("alias")
+  DO UPDATE SET
+    "url" = excluded."url";
+
+-- name: DeleteArtistAlias :exec
+DELETE FROM "ArtistAlias"
+WHERE "alias" = @alias;
+
+-- name: MoveArtist :exec
+UPDATE
+  "Artist"
+SET
+  "url" = @newUrl
+WHERE
+  "url" = @oldUrl;
+
+-- name: MoveArtistAliases :exec
+UPDATE
+  "ArtistAlias"
+SET
+  "url" = @newUrl
+WHERE
+  "url" = @oldUrl;
+
+-- name: MoveArtistExternalIDs :exec
+UPDATE
+  "ArtistExternalID"
+SET
+  "artistUrl" = @newUrl
+WHERE
+  "artistUrl" = @oldUrl;
+
+-- name: DeleteArtistAlbumsNamed :exec
+DELETE FROM "Album"
+WHERE "artistUrl" = @artistUrl
+  AND "name" IN (sqlc.slice(names));
+
+-- name: MoveArtistAlbums :exec
+UPDATE
+  "Album"
+SET
+  "artistUrl" = @newUrl,
+  "pageURL" = CASE WHEN "pageURL" = @oldUrl THEN
+    @newUrl
+  ELSE
+    "pageURL"
+  END
+WHERE
+  "artistUrl" = @oldUrl;
+
+-- name: ListArtistAlbumAliases :many
+SELECT
+  "alias"
+FROM
+  "AlbumAlias"
+WHERE
+  "artistUrl" = @artistUrl;
+
+-- name: DeleteArtistAlbumAliases :exec
+DELETE FROM "AlbumAlias"
+WHERE "artistUrl" = @artistUrl
+  AND "alias" IN (sqlc.slice(aliases));
+
+-- name: MoveAlbumAliases :exec
+UPDATE
+  "AlbumAlias"
+SET
+  "artistUrl" = @newUrl
+WHERE
+  "artistUrl" = @oldUrl;
+
+-- name: MoveRelatedArtists :exec
+UPDATE OR IGNORE
+  "_RelatedArtists"
+SET
+  "A" = CASE WHEN "A" = @oldUrl THEN
+    @newUrl
+  ELSE
+    "A"
+  END,
+  "B" = CASE WHEN "B" = @oldUrl THEN
+    @newUrl
+  ELSE
+    "B"
+  END
+WHERE
+  "A" = @oldUrl
+  OR "B" = @oldUrl;
+
+-- name: DeleteArtistEdges :exec
+DELETE FROM "_RelatedArtists"
+WHERE "A" = @url
+  OR "B" = @url;
+
+-- name: InsertUpdateRun :one
+INSERT INTO "UpdateRun" ("kind", "startedAt")
+  VALUES (@kind, @startedAt)
+RETURNING
+  "id";
+
+-- name: FinishUpdateRun :exec
+UPDATE
+  "UpdateRun"
+SET
+  "endedAt" = @endedAt
+WHERE
+  "id" = @id;
+
+-- name: InsertRatingChange :execrows
+INSERT INTO "RatingHistory" ("artistUrl", "albumName", "oldRating", "newRating", "pageURL", "runId", "changedAt")
+SELECT
+  "a"."artistUrl",
+  "a"."name",
+  "a"."rating",
+  @newRating,
+  @pageURL,
+  @runId,
+  @changedAt
+FROM
+  "Album" "a"
+WHERE
+  "a"."artistUrl" = @artistUrl
+  AND "a"."name" = @name
+  AND "a"."rating" != @newRating;
+
+-- name: ListAlbumRatingHistory :many
+SELECT
+  *
+FROM
+  "RatingHistory"
+WHERE
+  "artistUrl" = @artistUrl
+  AND "albumName" = @albumName
+ORDER BY
+  "changedAt";
+
+-- name: ListArtistRatingHistory :many
+SELECT
+  *
+FROM
+  "RatingHistory"
+WHERE
+  "artistUrl" = @artistUrl
+ORDER BY
+  "changedAt",
+  "albumName";
+
+-- name: MoveRatingHistory :exec
+UPDATE
+  "RatingHistory"
+SET
+  "artistUrl" = @newUrl
+WHERE
+  "artistUrl" = @oldUrl;
+
+-- name: InsertStoredBioRevision :exec
+INSERT INTO "BioRevision" ("artistUrl", "bio", "bioMarkdown", "runId", "createdAt")
+SELECT
+  "url",
+  "bio",
+  "bioMarkdown",
+  NULL,
+  "lastModified"
+FROM
+  "Artist"
+WHERE
+  "url" = @url
+  AND "bio" IS NOT NULL
+  AND NOT EXISTS (
+    SELECT
+      1
+    FROM
+      "BioRevision"
+    WHERE
+      "artistUrl" = @url
+  );
+
+-- name: InsertBioRevision :execrows
+INSERT INTO "BioRevision" ("artistUrl", "bio", "bioMarkdown", "runId", "createdAt")
+SELECT
+  @artistUrl,
+  @bio,
+  @bioMarkdown,
+  @runId,
+  @createdAt
+WHERE
+  @bio IS NOT (
+    SELECT
+      "bio"
+    FROM
+      "BioRevision"
+    WHERE
+      "artistUrl" = @artistUrl
+    ORDER BY
+      "id" DESC
+    LIMIT
+      1
+  );
+
+-- name: ListBioRevisions :many
+SELECT
+  *
+FROM
+  "BioRevision"
+WHERE
+  "artistUrl" = @artistUrl
+ORDER BY
+  "id";
+
+-- name: GetBioRevision :one
+SELECT
+  *
+FROM
+  "BioRevision"
+WHERE
+  "artistUrl" = @artistUrl
+  AND "id" = @id;
+
+-- name: MoveBioRevisions :exec
+UPDATE
+  "BioRevision"
+SET
+  "artistUrl" = @newUrl
+WHERE
+  "artistUrl" = @oldUrl;
+
+-- name: UpdateContentHash :execrows
+UPDATE
+  "UpdateHistory"
+SET
+  "contentHash" = @contentHash
+WHERE
+  "pageURL" = @pageURL
+  AND ("contentHash" IS NULL
+    OR "contentHash" != @contentHash);
+
+-- name: GetPageParse :one
+SELECT
+  *
+FROM
+  "PageParse"
+WHERE
+  "pageURL" = @pageURL;
+
+-- name: UpsertPageParse :exec
+INSERT INTO "PageParse" ("pageURL", "albums", "bioLength", "links", "parsedAt")
+  VALUES (@pageURL, @albums, @bioLength, @links, @parsedAt)
+ON CONFLICT ("pageURL")
+  DO UPDATE SET
+    "albums" = excluded."albums", "bioLength" = excluded."bioLength",
+      "links" = excluded."links", "parsedAt" = excluded."parsedAt";
+
+-- name: UpsertPageQuarantine :exec
+INSERT INTO "PageQuarantine" ("pageURL", "reason", "quarantinedAt")
+  VALUES (@pageURL, @reason, @quarantinedAt)
+ON CONFLICT ("pageURL")
+  DO UPDATE SET
+    "reason" = excluded."reason", "quarantinedAt" = excluded."quarantinedAt";
+
+-- name: DeletePageQuarantine :execrows
+DELETE FROM "PageQuarantine"
+WHERE "pageURL" = @pageURL;
+
+-- name: DeletePageParse :exec
+DELETE FROM "PageParse"
+WHERE "pageURL" = @pageURL;
+
+-- name: ListPageQuarantine :many
+SELECT
+  *
+FROM
+  "PageQuarantine"
+ORDER BY
+  "quarantinedAt" DESC;
+
+-- name: InvalidateUpdateHistory :exec
+UPDATE
+  "UpdateHistory"
+SET
+  "hash" = '',
+  "contentHash" = NULL,
+  "etag" = NULL,
+  "lastModified" = NULL
+WHERE
+  "pageURL" = @pageURL;
+
+-- name: UpsertPageDiagnostics :exec
+INSERT INTO "PageDiagnostics" ("pageURL", "nameStrategy", "bioStrategy", "albumMisses", "skippedRows", "diagnostics", "recordedAt")
+  VALUES (@pageURL, @nameStrategy, @bioStrategy, @albumMisses, @skippedRows, @diagnostics, @recordedAt)
+ON CONFLICT ("pageURL")
+  DO UPDATE SET
+    "nameStrategy" = excluded."nameStrategy", "bioStrategy" = excluded."bioStrategy",
+      "albumMisses" = excluded."albumMisses", "skippedRows" = excluded."skippedRows",
+      "diagnostics" = excluded."diagnostics", "recordedAt" = excluded."recordedAt";
+
+-- name: GetPageDiagnostics :one
+SELECT
+  *
+FROM
+  "PageDiagnostics"
+WHERE
+  "pageURL" = @pageURL;
+
+-- name: ListPageDiagnostics :many
+SELECT
+  *
+FROM
+  "PageDiagnostics"
+WHERE
+  "albumMisses" > 0
+  OR "skippedRows" > 0
+  OR "bioStrategy" = 'none'
+ORDER BY
+  "pageURL";
+
+-- name: UpsertRanking :exec
+INSERT INTO "Ranking" ("pageURL", "title", "lastModified")
+  VALUES (@pageURL, @title, DATETIME('now'))
+ON CONFLICT ("pageURL")
+  DO UPDATE SET
+    "title" = excluded."title", "lastModified" = excluded."lastModified";
+
+-- name: DeleteRankingEntries :exec
+DELETE FROM "RankingEntry"
+WHERE "rankingUrl" = @rankingUrl;
+
+-- name: InsertRankingEntry :exec
+INSERT INTO "RankingEntry" ("rankingUrl", "position", "rank", "artistName", "albumTitle", "year", "artistLink", "artistUrl", "albumName")
+  VALUES (@rankingUrl, @position, @rank, @artistName, @albumTitle, @year, @artistLink, @artistUrl, @albumName);
+
+-- name: ListUnlinkedRankingEntries :many
+SELECT
+  *
+FROM
+  "RankingEntry"
+WHERE
+  "albumName" IS NULL
+  AND "artistLink" IS NOT NULL;
+
+-- name: LinkRankingEntry :exec
+UPDATE
+  "RankingEntry"
+SET
+  "artistUrl" = @artistUrl,
+  "albumName" = @albumName
+WHERE
+  "rankingUrl" = @rankingUrl
+  AND "position" = @position;
+
+-- name: MoveRankingEntries :exec
+UPDATE
+  "RankingEntry"
+SET
+  "artistUrl" = @newUrl
+WHERE
+  "artistUrl" = @oldUrl;
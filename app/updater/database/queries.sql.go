@@ -8,6 +8,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"time"
 )
 
@@ -29,20 +30,1467 @@ func (q *Queries) CheckPageExists(ctx context.Context, hash string) (int64, erro
 	return column_1, err
 }
 
+const deleteArtistAlbumAliases = `-- name: DeleteArtistAlbumAliases :exec
+DELETE FROM "AlbumAlias"
+WHERE "artistUrl" = ?1
+  AND "alias" IN (/*SLICE:aliases*/?)
+`
+
+type DeleteArtistAlbumAliasesParams struct {
+	ArtistUrl string
+	Aliases   []string
+}
+
+func (q *Queries) DeleteArtistAlbumAliases(ctx context.Context, arg DeleteArtistAlbumAliasesParams) error {
+	query := deleteArtistAlbumAliases
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.ArtistUrl)
+	if len(arg.Aliases) > 0 {
+		for _, v := range arg.Aliases {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:aliases*/?", strings.Repeat(",?", len(arg.Aliases))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:aliases*/?", "NULL", 1)
+	}
+	_, err := q.db.ExecContext(ctx, query, queryParams...)
+	return err
+}
+
+const deleteArtistAlbumsNamed = `-- name: DeleteArtistAlbumsNamed :exec
+DELETE FROM "Album"
+WHERE "artistUrl" = ?1
+  AND "name" IN (/*SLICE:names*/?)
+`
+
+type DeleteArtistAlbumsNamedParams struct {
+	ArtistUrl string
+	Names     []string
+}
+
+func (q *Queries) DeleteArtistAlbumsNamed(ctx context.Context, arg DeleteArtistAlbumsNamedParams) error {
+	query := deleteArtistAlbumsNamed
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.ArtistUrl)
+	if len(arg.Names) > 0 {
+		for _, v := range arg.Names {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:names*/?", strings.Repeat(",?", len(arg.Names))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:names*/?", "NULL", 1)
+	}
+	_, err := q.db.ExecContext(ctx, query, queryParams...)
+	return err
+}
+
+const deleteArtistAlias = `-- name: DeleteArtistAlias :exec
+DELETE FROM "ArtistAlias"
+WHERE "alias" = ?1
+`
+
+func (q *Queries) DeleteArtistAlias(ctx context.Context, alias string) error {
+	_, err := q.db.ExecContext(ctx, deleteArtistAlias, alias)
+	return err
+}
+
+const deleteArtistEdges = `-- name: DeleteArtistEdges :exec
+DELETE FROM "_RelatedArtists"
+WHERE "A" = ?1
+  OR "B" = ?1
+`
+
+func (q *Queries) DeleteArtistEdges(ctx context.Context, url string) error {
+	_, err := q.db.ExecContext(ctx, deleteArtistEdges, url)
+	return err
+}
+
+const deletePageParse = `-- name: DeletePageParse :exec
+DELETE FROM "PageParse"
+WHERE "pageURL" = ?1
+`
+
+func (q *Queries) DeletePageParse(ctx context.Context, pageurl string) error {
+	_, err := q.db.ExecContext(ctx, deletePageParse, pageurl)
+	return err
+}
+
+const deletePageQuarantine = `-- name: DeletePageQuarantine :execrows
+DELETE FROM "PageQuarantine"
+WHERE "pageURL" = ?1
+`
+
+func (q *Queries) DeletePageQuarantine(ctx context.Context, pageurl string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePageQuarantine, pageurl)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteRankingEntries = `-- name: DeleteRankingEntries :exec
+DELETE FROM "RankingEntry"
+WHERE "rankingUrl" = ?1
+`
+
+func (q *Queries) DeleteRankingEntries(ctx context.Context, rankingurl string) error {
+	_, err := q.db.ExecContext(ctx, deleteRankingEntries, rankingurl)
+	return err
+}
+
+const deleteRelatedArtists = `-- name: DeleteRelatedArtists :exec
+DELETE FROM "_RelatedArtists"
+WHERE "A" = ?1
+`
+
+func (q *Queries) DeleteRelatedArtists(ctx context.Context, a string) error {
+	_, err := q.db.ExecContext(ctx, deleteRelatedArtists, a)
+	return err
+}
+
+const findMovedArtist = `-- name: FindMovedArtist :one
+SELECT
+  "url"
+FROM
+  "Artist"
+WHERE
+  "name" = ?1
+  AND "bio" = ?2
+  AND "url" != ?3
+ORDER BY
+  "lastModified" DESC
+LIMIT 1
+`
+
+type FindMovedArtistParams struct {
+	Name string
+	Bio  sql.NullString
+	Url  string
+}
+
+func (q *Queries) FindMovedArtist(ctx context.Context, arg FindMovedArtistParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, findMovedArtist, arg.Name, arg.Bio, arg.Url)
+	var url string
+	err := row.Scan(&url)
+	return url, err
+}
+
+const finishUpdateRun = `-- name: FinishUpdateRun :exec
+UPDATE
+  "UpdateRun"
+SET
+  "endedAt" = ?1
+WHERE
+  "id" = ?2
+`
+
+type FinishUpdateRunParams struct {
+	EndedAt sql.NullTime
+	ID      int64
+}
+
+func (q *Queries) FinishUpdateRun(ctx context.Context, arg FinishUpdateRunParams) error {
+	_, err := q.db.ExecContext(ctx, finishUpdateRun, arg.EndedAt, arg.ID)
+	return err
+}
+
+const getAlbum = `-- name: GetAlbum :one
+SELECT
+  name, year, rating, artistUrl, imageUrl, pageURL, position, kind, credits, seenAt, deletedAt
+FROM
+  "Album"
+WHERE
+  "artistUrl" = ?1
+  AND "name" = ?2
+`
+
+type GetAlbumParams struct {
+	ArtistUrl string
+	Name      string
+}
+
+func (q *Queries) GetAlbum(ctx context.Context, arg GetAlbumParams) (Album, error) {
+	row := q.db.QueryRowContext(ctx, getAlbum, arg.ArtistUrl, arg.Name)
+	var i Album
+	err := row.Scan(
+		&i.Name,
+		&i.Year,
+		&i.Rating,
+		&i.ArtistUrl,
+		&i.ImageUrl,
+		&i.PageURL,
+		&i.Position,
+		&i.Kind,
+		&i.Credits,
+		&i.SeenAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getAlbumAliasName = `-- name: GetAlbumAliasName :one
+SELECT
+  "name"
+FROM
+  "AlbumAlias"
+WHERE
+  "artistUrl" = ?1
+  AND "normalized" = ?2
+LIMIT 1
+`
+
+type GetAlbumAliasNameParams struct {
+	ArtistUrl  string
+	Normalized string
+}
+
+func (q *Queries) GetAlbumAliasName(ctx context.Context, arg GetAlbumAliasNameParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, getAlbumAliasName, arg.ArtistUrl, arg.Normalized)
+	var name string
+	err := row.Scan(&name)
+	return name, err
+}
+
+const getArtist = `-- name: GetArtist :one
+SELECT
+  url, name, bio, imageUrl, lastModified, bioMarkdown, bioLanguage, notFoundAt, deletedAt
+FROM
+  "Artist"
+WHERE
+  "url" = ?1
+`
+
+func (q *Queries) GetArtist(ctx context.Context, url string) (Artist, error) {
+	row := q.db.QueryRowContext(ctx, getArtist, url)
+	var i Artist
+	err := row.Scan(
+		&i.Url,
+		&i.Name,
+		&i.Bio,
+		&i.ImageUrl,
+		&i.LastModified,
+		&i.BioMarkdown,
+		&i.BioLanguage,
+		&i.NotFoundAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getArtistAlias = `-- name: GetArtistAlias :one
+SELECT
+  "url"
+FROM
+  "ArtistAlias"
+WHERE
+  LOWER("alias") = LOWER(?1)
+`
+
+func (q *Queries) GetArtistAlias(ctx context.Context, alias string) (string, error) {
+	row := q.db.QueryRowContext(ctx, getArtistAlias, alias)
+	var url string
+	err := row.Scan(&url)
+	return url, err
+}
+
+const getArtistURLByKey = `-- name: GetArtistURLByKey :one
+SELECT
+  "url"
+FROM
+  "Artist"
+WHERE
+  LOWER("url") = LOWER(?1)
+LIMIT 1
+`
+
+func (q *Queries) GetArtistURLByKey(ctx context.Context, url string) (string, error) {
+	row := q.db.QueryRowContext(ctx, getArtistURLByKey, url)
+	err := row.Scan(&url)
+	return url, err
+}
+
+const getBioRevision = `-- name: GetBioRevision :one
+SELECT
+  id, artistUrl, bio, bioMarkdown, runId, createdAt
+FROM
+  "BioRevision"
+WHERE
+  "artistUrl" = ?1
+  AND "id" = ?2
+`
+
+type GetBioRevisionParams struct {
+	ArtistUrl string
+	ID        int64
+}
+
+func (q *Queries) GetBioRevision(ctx context.Context, arg GetBioRevisionParams) (BioRevision, error) {
+	row := q.db.QueryRowContext(ctx, getBioRevision, arg.ArtistUrl, arg.ID)
+	var i BioRevision
+	err := row.Scan(
+		&i.ID,
+		&i.ArtistUrl,
+		&i.Bio,
+		&i.BioMarkdown,
+		&i.RunId,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPageDiagnostics = `-- name: GetPageDiagnostics :one
+SELECT
+  pageURL, nameStrategy, bioStrategy, albumMisses, skippedRows, diagnostics, recordedAt
+FROM
+  "PageDiagnostics"
+WHERE
+  "pageURL" = ?1
+`
+
+func (q *Queries) GetPageDiagnostics(ctx context.Context, pageurl string) (PageDiagnostics, error) {
+	row := q.db.QueryRowContext(ctx, getPageDiagnostics, pageurl)
+	var i PageDiagnostics
+	err := row.Scan(
+		&i.PageURL,
+		&i.NameStrategy,
+		&i.BioStrategy,
+		&i.AlbumMisses,
+		&i.SkippedRows,
+		&i.Diagnostics,
+		&i.RecordedAt,
+	)
+	return i, err
+}
+
+const getPageParse = `-- name: GetPageParse :one
+SELECT
+  pageURL, albums, bioLength, links, parsedAt
+FROM
+  "PageParse"
+WHERE
+  "pageURL" = ?1
+`
+
+func (q *Queries) GetPageParse(ctx context.Context, pageurl string) (PageParse, error) {
+	row := q.db.QueryRowContext(ctx, getPageParse, pageurl)
+	var i PageParse
+	err := row.Scan(
+		&i.PageURL,
+		&i.Albums,
+		&i.BioLength,
+		&i.Links,
+		&i.ParsedAt,
+	)
+	return i, err
+}
+
+const getPageSnapshot = `-- name: GetPageSnapshot :one
+SELECT
+  hash, content, createdAt
+FROM
+  "PageSnapshot"
+WHERE
+  "hash" = ?1
+`
+
+func (q *Queries) GetPageSnapshot(ctx context.Context, hash string) (PageSnapshot, error) {
+	row := q.db.QueryRowContext(ctx, getPageSnapshot, hash)
+	var i PageSnapshot
+	err := row.Scan(&i.Hash, &i.Content, &i.CreatedAt)
+	return i, err
+}
+
 const getUpdateHistory = `-- name: GetUpdateHistory :one
 SELECT
-  checkedOn, hash, pageURL
+  checkedOn, hash, pageURL, snapshotHash, etag, lastModified, contentHash
+FROM
+  "UpdateHistory"
+WHERE
+  "pageURL" = ?1
+`
+
+func (q *Queries) GetUpdateHistory(ctx context.Context, pageurl string) (UpdateHistory, error) {
+	row := q.db.QueryRowContext(ctx, getUpdateHistory, pageurl)
+	var i UpdateHistory
+	err := row.Scan(
+		&i.CheckedOn,
+		&i.Hash,
+		&i.PageURL,
+		&i.SnapshotHash,
+		&i.Etag,
+		&i.LastModified,
+		&i.ContentHash,
+	)
+	return i, err
+}
+
+const insertBioRevision = `-- name: InsertBioRevision :execrows
+INSERT INTO "BioRevision" ("artistUrl", "bio", "bioMarkdown", "runId", "createdAt")
+SELECT
+  ?1,
+  ?2,
+  ?3,
+  ?4,
+  ?5
+WHERE
+  ?2 IS NOT (
+    SELECT
+      "bio"
+    FROM
+      "BioRevision"
+    WHERE
+      "artistUrl" = @artistUrl
+    ORDER BY
+      "id" DESC
+    LIMIT
+      1
+  )
+`
+
+type InsertBioRevisionParams struct {
+	ArtistUrl   string
+	Bio         string
+	BioMarkdown sql.NullString
+	RunId       sql.NullInt64
+	CreatedAt   time.Time
+}
+
+func (q *Queries) InsertBioRevision(ctx context.Context, arg InsertBioRevisionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, insertBioRevision,
+		arg.ArtistUrl,
+		arg.Bio,
+		arg.BioMarkdown,
+		arg.RunId,
+		arg.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const insertPageSnapshot = `-- name: InsertPageSnapshot :exec
+INSERT INTO "PageSnapshot" ("hash", "content", "createdAt")
+  VALUES (?1, ?2, ?3)
+ON CONFLICT ("hash")
+  DO NOTHING
+`
+
+type InsertPageSnapshotParams struct {
+	Hash      string
+	Content   []byte
+	CreatedAt time.Time
+}
+
+func (q *Queries) InsertPageSnapshot(ctx context.Context, arg InsertPageSnapshotParams) error {
+	_, err := q.db.ExecContext(ctx, insertPageSnapshot, arg.Hash, arg.Content, arg.CreatedAt)
+	return err
+}
+
+const insertRankingEntry = `-- name: InsertRankingEntry :exec
+INSERT INTO "RankingEntry" ("rankingUrl", "position", "rank", "artistName", "albumTitle", "year", "artistLink", "artistUrl", "albumName")
+  VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9)
+`
+
+type InsertRankingEntryParams struct {
+	RankingUrl string
+	Position   int64
+	Rank       int64
+	ArtistName string
+	AlbumTitle string
+	Year       sql.NullInt64
+	ArtistLink sql.NullString
+	ArtistUrl  sql.NullString
+	AlbumName  sql.NullString
+}
+
+func (q *Queries) InsertRankingEntry(ctx context.Context, arg InsertRankingEntryParams) error {
+	_, err := q.db.ExecContext(ctx, insertRankingEntry,
+		arg.RankingUrl,
+		arg.Position,
+		arg.Rank,
+		arg.ArtistName,
+		arg.AlbumTitle,
+		arg.Year,
+		arg.ArtistLink,
+		arg.ArtistUrl,
+		arg.AlbumName,
+	)
+	return err
+}
+
+const insertRatingChange = `-- name: InsertRatingChange :execrows
+INSERT INTO "RatingHistory" ("artistUrl", "albumName", "oldRating", "newRating", "pageURL", "runId", "changedAt")
+SELECT
+  "a"."artistUrl",
+  "a"."name",
+  "a"."rating",
+  ?1,
+  ?2,
+  ?3,
+  ?4
+FROM
+  "Album" "a"
+WHERE
+  "a"."artistUrl" = ?5
+  AND "a"."name" = ?6
+  AND "a"."rating" != ?1
+`
+
+type InsertRatingChangeParams struct {
+	NewRating float64
+	PageURL   string
+	RunId     sql.NullInt64
+	ChangedAt time.Time
+	ArtistUrl string
+	Name      string
+}
+
+func (q *Queries) InsertRatingChange(ctx context.Context, arg InsertRatingChangeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, insertRatingChange,
+		arg.NewRating,
+		arg.PageURL,
+		arg.RunId,
+		arg.ChangedAt,
+		arg.ArtistUrl,
+		arg.Name,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const insertRelatedArtist = `-- name: InsertRelatedArtist :exec
+INSERT INTO "_RelatedArtists" ("A", "B")
+SELECT
+  "a"."url",
+  "b"."url"
 FROM
+  "Artist" AS "a",
+  "Artist" AS "b"
+WHERE
+  "a"."url" = ?1
+  AND "b"."url" = ?2
+ON CONFLICT ("A", "B")
+  DO NOTHING
+`
+
+type InsertRelatedArtistParams struct {
+	A string
+	B string
+}
+
+func (q *Queries) InsertRelatedArtist(ctx context.Context, arg InsertRelatedArtistParams) error {
+	_, err := q.db.ExecContext(ctx, insertRelatedArtist, arg.A, arg.B)
+	return err
+}
+
+const insertStoredBioRevision = `-- name: InsertStoredBioRevision :exec
+INSERT INTO "BioRevision" ("artistUrl", "bio", "bioMarkdown", "runId", "createdAt")
+SELECT
+  "url",
+  "bio",
+  "bioMarkdown",
+  NULL,
+  "lastModified"
+FROM
+  "Artist"
+WHERE
+  "url" = ?1
+  AND "bio" IS NOT NULL
+  AND NOT EXISTS (
+    SELECT
+      1
+    FROM
+      "BioRevision"
+    WHERE
+      "artistUrl" = @url
+  )
+`
+
+func (q *Queries) InsertStoredBioRevision(ctx context.Context, url string) error {
+	_, err := q.db.ExecContext(ctx, insertStoredBioRevision, url)
+	return err
+}
+
+const insertUpdateRun = `-- name: InsertUpdateRun :one
+INSERT INTO "UpdateRun" ("kind", "startedAt")
+  VALUES (?1, ?2)
+RETURNING
+  "id"
+`
+
+type InsertUpdateRunParams struct {
+	Kind      string
+	StartedAt time.Time
+}
+
+func (q *Queries) InsertUpdateRun(ctx context.Context, arg InsertUpdateRunParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, insertUpdateRun, arg.Kind, arg.StartedAt)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const invalidateUpdateHistory = `-- name: InvalidateUpdateHistory :exec
+UPDATE
+  "UpdateHistory"
+SET
+  "hash" = '',
+  "contentHash" = NULL,
+  "etag" = NULL,
+  "lastModified" = NULL
+WHERE
+  "pageURL" = ?1
+`
+
+func (q *Queries) InvalidateUpdateHistory(ctx context.Context, pageurl string) error {
+	_, err := q.db.ExecContext(ctx, invalidateUpdateHistory, pageurl)
+	return err
+}
+
+const linkRankingEntry = `-- name: LinkRankingEntry :exec
+UPDATE
+  "RankingEntry"
+SET
+  "artistUrl" = ?1,
+  "albumName" = ?2
+WHERE
+  "rankingUrl" = ?3
+  AND "position" = ?4
+`
+
+type LinkRankingEntryParams struct {
+	ArtistUrl  sql.NullString
+	AlbumName  sql.NullString
+	RankingUrl string
+	Position   int64
+}
+
+func (q *Queries) LinkRankingEntry(ctx context.Context, arg LinkRankingEntryParams) error {
+	_, err := q.db.ExecContext(ctx, linkRankingEntry,
+		arg.ArtistUrl,
+		arg.AlbumName,
+		arg.RankingUrl,
+		arg.Position,
+	)
+	return err
+}
+
+const listAlbumRatingHistory = `-- name: ListAlbumRatingHistory :many
+SELECT
+  id, artistUrl, albumName, oldRating, newRating, pageURL, runId, changedAt
+FROM
+  "RatingHistory"
+WHERE
+  "artistUrl" = ?1
+  AND "albumName" = ?2
+ORDER BY
+  "changedAt"
+`
+
+type ListAlbumRatingHistoryParams struct {
+	ArtistUrl string
+	AlbumName string
+}
+
+func (q *Queries) ListAlbumRatingHistory(ctx context.Context, arg ListAlbumRatingHistoryParams) ([]RatingHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listAlbumRatingHistory, arg.ArtistUrl, arg.AlbumName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RatingHistory
+	for rows.Next() {
+		var i RatingHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.ArtistUrl,
+			&i.AlbumName,
+			&i.OldRating,
+			&i.NewRating,
+			&i.PageURL,
+			&i.RunId,
+			&i.ChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listArtistAlbumAliases = `-- name: ListArtistAlbumAliases :many
+SELECT
+  "alias"
+FROM
+  "AlbumAlias"
+WHERE
+  "artistUrl" = ?1
+`
+
+func (q *Queries) ListArtistAlbumAliases(ctx context.Context, artisturl string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listArtistAlbumAliases, artisturl)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, err
+		}
+		items = append(items, alias)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listArtistAlbumNames = `-- name: ListArtistAlbumNames :many
+SELECT
+  "name"
+FROM
+  "Album"
+WHERE
+  "artistUrl" = ?1
+ORDER BY
+  "name"
+`
+
+func (q *Queries) ListArtistAlbumNames(ctx context.Context, artisturl string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listArtistAlbumNames, artisturl)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listArtistRatingHistory = `-- name: ListArtistRatingHistory :many
+SELECT
+  id, artistUrl, albumName, oldRating, newRating, pageURL, runId, changedAt
+FROM
+  "RatingHistory"
+WHERE
+  "artistUrl" = ?1
+ORDER BY
+  "changedAt",
+  "albumName"
+`
+
+func (q *Queries) ListArtistRatingHistory(ctx context.Context, artisturl string) ([]RatingHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listArtistRatingHistory, artisturl)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RatingHistory
+	for rows.Next() {
+		var i RatingHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.ArtistUrl,
+			&i.AlbumName,
+			&i.OldRating,
+			&i.NewRating,
+			&i.PageURL,
+			&i.RunId,
+			&i.ChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBioRevisions = `-- name: ListBioRevisions :many
+SELECT
+  id, artistUrl, bio, bioMarkdown, runId, createdAt
+FROM
+  "BioRevision"
+WHERE
+  "artistUrl" = ?1
+ORDER BY
+  "id"
+`
+
+func (q *Queries) ListBioRevisions(ctx context.Context, artisturl string) ([]BioRevision, error) {
+	rows, err := q.db.QueryContext(ctx, listBioRevisions, artisturl)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BioRevision
+	for rows.Next() {
+		var i BioRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.ArtistUrl,
+			&i.Bio,
+			&i.BioMarkdown,
+			&i.RunId,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPageDiagnostics = `-- name: ListPageDiagnostics :many
+SELECT
+  pageURL, nameStrategy, bioStrategy, albumMisses, skippedRows, diagnostics, recordedAt
+FROM
+  "PageDiagnostics"
+WHERE
+  "albumMisses" > 0
+  OR "skippedRows" > 0
+  OR "bioStrategy" = 'none'
+ORDER BY
+  "pageURL"
+`
+
+func (q *Queries) ListPageDiagnostics(ctx context.Context) ([]PageDiagnostics, error) {
+	rows, err := q.db.QueryContext(ctx, listPageDiagnostics)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PageDiagnostics
+	for rows.Next() {
+		var i PageDiagnostics
+		if err := rows.Scan(
+			&i.PageURL,
+			&i.NameStrategy,
+			&i.BioStrategy,
+			&i.AlbumMisses,
+			&i.SkippedRows,
+			&i.Diagnostics,
+			&i.RecordedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPageQuarantine = `-- name: ListPageQuarantine :many
+SELECT
+  pageURL, reason, quarantinedAt
+FROM
+  "PageQuarantine"
+ORDER BY
+  "quarantinedAt" DESC
+`
+
+func (q *Queries) ListPageQuarantine(ctx context.Context) ([]PageQuarantine, error) {
+	rows, err := q.db.QueryContext(ctx, listPageQuarantine)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PageQuarantine
+	for rows.Next() {
+		var i PageQuarantine
+		if err := rows.Scan(&i.PageURL, &i.Reason, &i.QuarantinedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPageVersions = `-- name: ListPageVersions :many
+SELECT
+  pageURL, hash, firstSeen, lastSeen
+FROM
+  "PageVersion"
+WHERE
+  "pageURL" = ?1
+ORDER BY
+  "lastSeen" DESC
+`
+
+func (q *Queries) ListPageVersions(ctx context.Context, pageurl string) ([]PageVersion, error) {
+	rows, err := q.db.QueryContext(ctx, listPageVersions, pageurl)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PageVersion
+	for rows.Next() {
+		var i PageVersion
+		if err := rows.Scan(
+			&i.PageURL,
+			&i.Hash,
+			&i.FirstSeen,
+			&i.LastSeen,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSeenAlbumPages = `-- name: ListSeenAlbumPages :many
+SELECT DISTINCT
+  "pageURL"
+FROM
+  "Album"
+WHERE
+  "seenAt" >= ?1
+`
+
+func (q *Queries) ListSeenAlbumPages(ctx context.Context, since sql.NullTime) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listSeenAlbumPages, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var pageURL string
+		if err := rows.Scan(&pageURL); err != nil {
+			return nil, err
+		}
+		items = append(items, pageURL)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSnapshotPages = `-- name: ListSnapshotPages :many
+SELECT
+  "pageURL",
+  "snapshotHash"
+FROM
+  "UpdateHistory"
+WHERE
+  "snapshotHash" IS NOT NULL
+ORDER BY
+  "pageURL"
+`
+
+type ListSnapshotPagesRow struct {
+	PageURL      string
+	SnapshotHash sql.NullString
+}
+
+func (q *Queries) ListSnapshotPages(ctx context.Context) ([]ListSnapshotPagesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSnapshotPages)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSnapshotPagesRow
+	for rows.Next() {
+		var i ListSnapshotPagesRow
+		if err := rows.Scan(&i.PageURL, &i.SnapshotHash); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTombstonedAlbums = `-- name: ListTombstonedAlbums :many
+SELECT
+  "artistUrl",
+  "name",
+  "pageURL",
+  "deletedAt"
+FROM
+  "Album"
+WHERE
+  "deletedAt" IS NOT NULL
+ORDER BY
+  "deletedAt" DESC
+`
+
+type ListTombstonedAlbumsRow struct {
+	ArtistUrl string
+	Name      string
+	PageURL   string
+	DeletedAt sql.NullTime
+}
+
+func (q *Queries) ListTombstonedAlbums(ctx context.Context) ([]ListTombstonedAlbumsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTombstonedAlbums)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTombstonedAlbumsRow
+	for rows.Next() {
+		var i ListTombstonedAlbumsRow
+		if err := rows.Scan(
+			&i.ArtistUrl,
+			&i.Name,
+			&i.PageURL,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTombstonedArtists = `-- name: ListTombstonedArtists :many
+SELECT
+  "url",
+  "name",
+  "deletedAt"
+FROM
+  "Artist"
+WHERE
+  "deletedAt" IS NOT NULL
+ORDER BY
+  "deletedAt" DESC
+`
+
+type ListTombstonedArtistsRow struct {
+	Url       string
+	Name      string
+	DeletedAt sql.NullTime
+}
+
+func (q *Queries) ListTombstonedArtists(ctx context.Context) ([]ListTombstonedArtistsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTombstonedArtists)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTombstonedArtistsRow
+	for rows.Next() {
+		var i ListTombstonedArtistsRow
+		if err := rows.Scan(&i.Url, &i.Name, &i.DeletedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnlinkedRankingEntries = `-- name: ListUnlinkedRankingEntries :many
+SELECT
+  rankingUrl, position, rank, artistName, albumTitle, year, artistUrl, albumName, artistLink
+FROM
+  "RankingEntry"
+WHERE
+  "albumName" IS NULL
+  AND "artistLink" IS NOT NULL
+`
+
+func (q *Queries) ListUnlinkedRankingEntries(ctx context.Context) ([]RankingEntry, error) {
+	rows, err := q.db.QueryContext(ctx, listUnlinkedRankingEntries)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RankingEntry
+	for rows.Next() {
+		var i RankingEntry
+		if err := rows.Scan(
+			&i.RankingUrl,
+			&i.Position,
+			&i.Rank,
+			&i.ArtistName,
+			&i.AlbumTitle,
+			&i.Year,
+			&i.ArtistUrl,
+			&i.AlbumName,
+			&i.ArtistLink,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markArtistNotFound = `-- name: MarkArtistNotFound :exec
+UPDATE
+  "Artist"
+SET
+  "notFoundAt" = ?1
+WHERE
+  "url" = ?2
+`
+
+type MarkArtistNotFoundParams struct {
+	NotFoundAt sql.NullTime
+	Url        string
+}
+
+func (q *Queries) MarkArtistNotFound(ctx context.Context, arg MarkArtistNotFoundParams) error {
+	_, err := q.db.ExecContext(ctx, markArtistNotFound, arg.NotFoundAt, arg.Url)
+	return err
+}
+
+const moveAlbumAliases = `-- name: MoveAlbumAliases :exec
+UPDATE
+  "AlbumAlias"
+SET
+  "artistUrl" = ?1
+WHERE
+  "artistUrl" = ?2
+`
+
+type MoveAlbumAliasesParams struct {
+	NewUrl string
+	OldUrl string
+}
+
+func (q *Queries) MoveAlbumAliases(ctx context.Context, arg MoveAlbumAliasesParams) error {
+	_, err := q.db.ExecContext(ctx, moveAlbumAliases, arg.NewUrl, arg.OldUrl)
+	return err
+}
+
+const moveArtist = `-- name: MoveArtist :exec
+UPDATE
+  "Artist"
+SET
+  "url" = ?1
+WHERE
+  "url" = ?2
+`
+
+type MoveArtistParams struct {
+	NewUrl string
+	OldUrl string
+}
+
+func (q *Queries) MoveArtist(ctx context.Context, arg MoveArtistParams) error {
+	_, err := q.db.ExecContext(ctx, moveArtist, arg.NewUrl, arg.OldUrl)
+	return err
+}
+
+const moveArtistAlbums = `-- name: MoveArtistAlbums :exec
+UPDATE
+  "Album"
+SET
+  "artistUrl" = ?1,
+  "pageURL" = CASE WHEN "pageURL" = ?2 THEN
+    ?1
+  ELSE
+    "pageURL"
+  END
+WHERE
+  "artistUrl" = ?2
+`
+
+type MoveArtistAlbumsParams struct {
+	NewUrl string
+	OldUrl string
+}
+
+func (q *Queries) MoveArtistAlbums(ctx context.Context, arg MoveArtistAlbumsParams) error {
+	_, err := q.db.ExecContext(ctx, moveArtistAlbums, arg.NewUrl, arg.OldUrl)
+	return err
+}
+
+const moveArtistAliases = `-- name: MoveArtistAliases :exec
+UPDATE
+  "ArtistAlias"
+SET
+  "url" = ?1
+WHERE
+  "url" = ?2
+`
+
+type MoveArtistAliasesParams struct {
+	NewUrl string
+	OldUrl string
+}
+
+func (q *Queries) MoveArtistAliases(ctx context.Context, arg MoveArtistAliasesParams) error {
+	_, err := q.db.ExecContext(ctx, moveArtistAliases, arg.NewUrl, arg.OldUrl)
+	return err
+}
+
+const moveArtistExternalIDs = `-- name: MoveArtistExternalIDs :exec
+UPDATE
+  "ArtistExternalID"
+SET
+  "artistUrl" = ?1
+WHERE
+  "artistUrl" = ?2
+`
+
+type MoveArtistExternalIDsParams struct {
+	NewUrl string
+	OldUrl string
+}
+
+func (q *Queries) MoveArtistExternalIDs(ctx context.Context, arg MoveArtistExternalIDsParams) error {
+	_, err := q.db.ExecContext(ctx, moveArtistExternalIDs, arg.NewUrl, arg.OldUrl)
+	return err
+}
+
+const moveBioRevisions = `-- name: MoveBioRevisions :exec
+UPDATE
+  "BioRevision"
+SET
+  "artistUrl" = ?1
+WHERE
+  "artistUrl" = ?2
+`
+
+type MoveBioRevisionsParams struct {
+	NewUrl string
+	OldUrl string
+}
+
+func (q *Queries) MoveBioRevisions(ctx context.Context, arg MoveBioRevisionsParams) error {
+	_, err := q.db.ExecContext(ctx, moveBioRevisions, arg.NewUrl, arg.OldUrl)
+	return err
+}
+
+const moveRankingEntries = `-- name: MoveRankingEntries :exec
+UPDATE
+  "RankingEntry"
+SET
+  "artistUrl" = ?1
+WHERE
+  "artistUrl" = ?2
+`
+
+type MoveRankingEntriesParams struct {
+	NewUrl sql.NullString
+	OldUrl sql.NullString
+}
+
+func (q *Queries) MoveRankingEntries(ctx context.Context, arg MoveRankingEntriesParams) error {
+	_, err := q.db.ExecContext(ctx, moveRankingEntries, arg.NewUrl, arg.OldUrl)
+	return err
+}
+
+const moveRatingHistory = `-- name: MoveRatingHistory :exec
+UPDATE
+  "RatingHistory"
+SET
+  "artistUrl" = ?1
+WHERE
+  "artistUrl" = ?2
+`
+
+type MoveRatingHistoryParams struct {
+	NewUrl string
+	OldUrl string
+}
+
+func (q *Queries) MoveRatingHistory(ctx context.Context, arg MoveRatingHistoryParams) error {
+	_, err := q.db.ExecContext(ctx, moveRatingHistory, arg.NewUrl, arg.OldUrl)
+	return err
+}
+
+const moveRelatedArtists = `-- name: MoveRelatedArtists :exec
+UPDATE OR IGNORE
+  "_RelatedArtists"
+SET
+  "A" = CASE WHEN "A" = ?1 THEN
+    ?2
+  ELSE
+    "A"
+  END,
+  "B" = CASE WHEN "B" = ?1 THEN
+    ?2
+  ELSE
+    "B"
+  END
+WHERE
+  "A" = ?1
+  OR "B" = ?1
+`
+
+type MoveRelatedArtistsParams struct {
+	OldUrl string
+	NewUrl string
+}
+
+func (q *Queries) MoveRelatedArtists(ctx context.Context, arg MoveRelatedArtistsParams) error {
+	_, err := q.db.ExecContext(ctx, moveRelatedArtists, arg.OldUrl, arg.NewUrl)
+	return err
+}
+
+const prunePageSnapshots = `-- name: PrunePageSnapshots :execrows
+DELETE FROM "PageSnapshot"
+WHERE "hash" NOT IN (
+    SELECT
+      "hash"
+    FROM
+      "PageVersion")
+  AND "hash" NOT IN (
+    SELECT
+      "snapshotHash"
+    FROM
+      "UpdateHistory"
+    WHERE
+      "snapshotHash" IS NOT NULL)
+`
+
+func (q *Queries) PrunePageSnapshots(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, prunePageSnapshots)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const prunePageVersions = `-- name: PrunePageVersions :execrows
+DELETE FROM "PageVersion" AS v
+WHERE NOT EXISTS (
+    SELECT
+      1
+    FROM
+      "UpdateHistory" h
+    WHERE
+      h."pageURL" = v."pageURL"
+      AND h."snapshotHash" = v."hash")
+  AND (v."lastSeen" < ?1
+    OR (
+      SELECT
+        COUNT(*)
+      FROM
+        "PageVersion" n
+      WHERE
+        n."pageURL" = v."pageURL"
+        AND n."lastSeen" > v."lastSeen") >= CAST(?2 AS INTEGER))
+`
+
+type PrunePageVersionsParams struct {
+	Cutoff time.Time
+	Keep   int64
+}
+
+func (q *Queries) PrunePageVersions(ctx context.Context, arg PrunePageVersionsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, prunePageVersions, arg.Cutoff, arg.Keep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const refreshUpdateHistory = `-- name: RefreshUpdateHistory :exec
+UPDATE
   "UpdateHistory"
+SET
+  "snapshotHash" = COALESCE(?1, "snapshotHash"),
+  "etag" = ?2,
+  "lastModified" = ?3
 WHERE
-  "pageURL" = ?1
+  "pageURL" = ?4
+  AND "hash" = ?5
 `
 
-func (q *Queries) GetUpdateHistory(ctx context.Context, pageurl string) (UpdateHistory, error) {
-	row := q.db.QueryRowContext(ctx, getUpdateHistory, pageurl)
-	var i UpdateHistory
-	err := row.Scan(&i.CheckedOn, &i.Hash, &i.PageURL)
-	return i, err
+type RefreshUpdateHistoryParams struct {
+	Snapshothash sql.NullString
+	Etag         sql.NullString
+	LastModified sql.NullTime
+	PageURL      string
+	Hash         string
+}
+
+func (q *Queries) RefreshUpdateHistory(ctx context.Context, arg RefreshUpdateHistoryParams) error {
+	_, err := q.db.ExecContext(ctx, refreshUpdateHistory,
+		arg.Snapshothash,
+		arg.Etag,
+		arg.LastModified,
+		arg.PageURL,
+		arg.Hash,
+	)
+	return err
 }
 
 const selectAllBios = `-- name: SelectAllBios :many
@@ -81,6 +1529,79 @@ func (q *Queries) SelectAllBios(ctx context.Context) ([]SelectAllBiosRow, error)
 	return items, nil
 }
 
+const tombstoneArtistAlbums = `-- name: TombstoneArtistAlbums :execrows
+UPDATE
+  "Album"
+SET
+  "deletedAt" = ?1
+WHERE
+  "deletedAt" IS NULL
+  AND "artistUrl" IN (
+    SELECT
+      "url"
+    FROM
+      "Artist"
+    WHERE
+      "deletedAt" IS NOT NULL)
+`
+
+func (q *Queries) TombstoneArtistAlbums(ctx context.Context, deletedat sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, tombstoneArtistAlbums, deletedat)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const tombstoneMissingAlbums = `-- name: TombstoneMissingAlbums :execrows
+UPDATE
+  "Album"
+SET
+  "deletedAt" = ?1
+WHERE
+  "deletedAt" IS NULL
+  AND "pageURL" = ?2
+  AND ("seenAt" IS NULL
+    OR "seenAt" < ?3)
+`
+
+type TombstoneMissingAlbumsParams struct {
+	DeletedAt sql.NullTime
+	PageURL   string
+	Since     sql.NullTime
+}
+
+func (q *Queries) TombstoneMissingAlbums(ctx context.Context, arg TombstoneMissingAlbumsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, tombstoneMissingAlbums, arg.DeletedAt, arg.PageURL, arg.Since)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const tombstoneNotFoundArtists = `-- name: TombstoneNotFoundArtists :execrows
+UPDATE
+  "Artist"
+SET
+  "deletedAt" = ?1
+WHERE
+  "deletedAt" IS NULL
+  AND "notFoundAt" >= ?2
+`
+
+type TombstoneNotFoundArtistsParams struct {
+	DeletedAt sql.NullTime
+	Since     sql.NullTime
+}
+
+func (q *Queries) TombstoneNotFoundArtists(ctx context.Context, arg TombstoneNotFoundArtistsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, tombstoneNotFoundArtists, arg.DeletedAt, arg.Since)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateAlbum = `-- name: UpdateAlbum :one
 UPDATE
   "Album"
@@ -97,7 +1618,12 @@ RETURNING
   "rating",
   "artistUrl",
   "imageUrl",
-  "pageURL"
+  "pageURL",
+  "position",
+  "kind",
+  "credits",
+  "seenAt",
+  "deletedAt"
 `
 
 type UpdateAlbumParams struct {
@@ -124,6 +1650,11 @@ func (q *Queries) UpdateAlbum(ctx context.Context, arg UpdateAlbumParams) (Album
 		&i.ArtistUrl,
 		&i.ImageUrl,
 		&i.PageURL,
+		&i.Position,
+		&i.Kind,
+		&i.Credits,
+		&i.SeenAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -141,7 +1672,11 @@ RETURNING
   "name",
   "bio",
   "imageUrl",
-  "lastModified"
+  "lastModified",
+  "bioMarkdown",
+  "bioLanguage",
+  "notFoundAt",
+  "deletedAt"
 `
 
 type UpdateArtistNameAndImageParams struct {
@@ -159,6 +1694,10 @@ func (q *Queries) UpdateArtistNameAndImage(ctx context.Context, arg UpdateArtist
 		&i.Bio,
 		&i.ImageUrl,
 		&i.LastModified,
+		&i.BioMarkdown,
+		&i.BioLanguage,
+		&i.NotFoundAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -167,28 +1706,57 @@ const updateBio = `-- name: UpdateBio :exec
 UPDATE
   "Artist"
 SET
-  "bio" = ?1
+  "bio" = ?1,
+  "bioMarkdown" = COALESCE(?2, "bioMarkdown")
 WHERE
-  "url" = ?2
+  "url" = ?3
 `
 
 type UpdateBioParams struct {
-	Bio sql.NullString
-	Url string
+	Bio         sql.NullString
+	Biomarkdown sql.NullString
+	Url         string
 }
 
 func (q *Queries) UpdateBio(ctx context.Context, arg UpdateBioParams) error {
-	_, err := q.db.ExecContext(ctx, updateBio, arg.Bio, arg.Url)
+	_, err := q.db.ExecContext(ctx, updateBio, arg.Bio, arg.Biomarkdown, arg.Url)
 	return err
 }
 
+const updateContentHash = `-- name: UpdateContentHash :execrows
+UPDATE
+  "UpdateHistory"
+SET
+  "contentHash" = ?1
+WHERE
+  "pageURL" = ?2
+  AND ("contentHash" IS NULL
+    OR "contentHash" != ?1)
+`
+
+type UpdateContentHashParams struct {
+	ContentHash sql.NullString
+	PageURL     string
+}
+
+func (q *Queries) UpdateContentHash(ctx context.Context, arg UpdateContentHashParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateContentHash, arg.ContentHash, arg.PageURL)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const upsertAlbum = `-- name: UpsertAlbum :exec
-INSERT INTO "Album" ("name", "year", "rating", "artistUrl", "imageUrl", "pageURL")
-  VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+INSERT INTO "Album" ("name", "year", "rating", "artistUrl", "imageUrl", "pageURL", "position", "kind", "credits", "seenAt")
+  VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10)
 ON CONFLICT ("artistUrl", "name")
   DO UPDATE SET
     "year" = excluded."year", "rating" = excluded."rating", "imageUrl" = excluded."imageUrl",
-      "pageURL" = excluded."pageURL"
+      "pageURL" = excluded."pageURL", "seenAt" = excluded."seenAt", "deletedAt" = NULL,
+      "position" = COALESCE(excluded."position", "position"),
+      "kind" = CASE WHEN excluded."position" IS NULL THEN "kind" ELSE excluded."kind" END,
+      "credits" = CASE WHEN excluded."position" IS NULL THEN "credits" ELSE excluded."credits" END
 `
 
 type UpsertAlbumParams struct {
@@ -198,6 +1766,10 @@ type UpsertAlbumParams struct {
 	ArtistUrl string
 	ImageUrl  sql.NullString
 	PageURL   string
+	Position  sql.NullInt64
+	Kind      sql.NullString
+	Credits   sql.NullString
+	SeenAt    sql.NullTime
 }
 
 func (q *Queries) UpsertAlbum(ctx context.Context, arg UpsertAlbumParams) error {
@@ -208,24 +1780,56 @@ func (q *Queries) UpsertAlbum(ctx context.Context, arg UpsertAlbumParams) error
 		arg.ArtistUrl,
 		arg.ImageUrl,
 		arg.PageURL,
+		arg.Position,
+		arg.Kind,
+		arg.Credits,
+		arg.SeenAt,
+	)
+	return err
+}
+
+const upsertAlbumAlias = `-- name: UpsertAlbumAlias :exec
+INSERT INTO "AlbumAlias" ("artistUrl", "alias", "normalized", "name")
+  VALUES (?1, ?2, ?3, ?4)
+ON CONFLICT ("artistUrl", "alias")
+  DO UPDATE SET
+    "normalized" = excluded."normalized", "name" = excluded."name"
+`
+
+type UpsertAlbumAliasParams struct {
+	ArtistUrl  string
+	Alias      string
+	Normalized string
+	Name       string
+}
+
+func (q *Queries) UpsertAlbumAlias(ctx context.Context, arg UpsertAlbumAliasParams) error {
+	_, err := q.db.ExecContext(ctx, upsertAlbumAlias,
+		arg.ArtistUrl,
+		arg.Alias,
+		arg.Normalized,
+		arg.Name,
 	)
 	return err
 }
 
 const upsertArtist = `-- name: UpsertArtist :exec
-INSERT INTO "Artist" ("url", "name", "bio", "imageUrl", "lastModified")
-  VALUES (?1, ?2, ?3, ?4, DATETIME('now'))
+INSERT INTO "Artist" ("url", "name", "bio", "bioMarkdown", "bioLanguage", "imageUrl", "lastModified")
+  VALUES (?1, ?2, ?3, ?4, ?5, ?6, DATETIME('now'))
 ON CONFLICT ("url")
   DO UPDATE SET
-    "name" = excluded."name", "bio" = excluded."bio", "imageUrl" = excluded."imageUrl",
-      "lastModified" = excluded."lastModified"
+    "name" = excluded."name", "bio" = excluded."bio", "bioMarkdown" = excluded."bioMarkdown",
+      "bioLanguage" = excluded."bioLanguage", "imageUrl" = excluded."imageUrl",
+      "lastModified" = excluded."lastModified", "notFoundAt" = NULL, "deletedAt" = NULL
 `
 
 type UpsertArtistParams struct {
-	Url      string
-	Name     string
-	Bio      sql.NullString
-	ImageUrl sql.NullString
+	Url         string
+	Name        string
+	Bio         sql.NullString
+	BioMarkdown sql.NullString
+	BioLanguage string
+	ImageUrl    sql.NullString
 }
 
 func (q *Queries) UpsertArtist(ctx context.Context, arg UpsertArtistParams) error {
@@ -233,32 +1837,208 @@ func (q *Queries) UpsertArtist(ctx context.Context, arg UpsertArtistParams) erro
 		arg.Url,
 		arg.Name,
 		arg.Bio,
+		arg.BioMarkdown,
+		arg.BioLanguage,
 		arg.ImageUrl,
 	)
 	return err
 }
 
-const upsertUpdateHistory = `-- name: UpsertUpdateHistory :one
-INSERT INTO "UpdateHistory" ("checkedOn", "hash", "pageURL")
+const upsertArtistAlias = `-- name: UpsertArtistAlias :exec
+INSERT INTO "ArtistAlias" ("alias", "url")
+  VALUES (?1, ?2)
+ON CONFLICT ("alias")
+  DO UPDATE SET
+    "url" = excluded."url"
+`
+
+type UpsertArtistAliasParams struct {
+	Alias string
+	Url   string
+}
+
+func (q *Queries) UpsertArtistAlias(ctx context.Context, arg UpsertArtistAliasParams) error {
+	_, err := q.db.ExecContext(ctx, upsertArtistAlias, arg.Alias, arg.Url)
+	return err
+}
+
+const upsertArtistExternalID = `-- name: UpsertArtistExternalID :exec
+INSERT INTO "ArtistExternalID" ("artistUrl", "service", "externalId")
+  VALUES (?1, ?2, ?3)
+ON CONFLICT ("artistUrl", "service")
+  DO UPDATE SET
+    "externalId" = excluded."externalId"
+`
+
+type UpsertArtistExternalIDParams struct {
+	ArtistUrl  string
+	Service    string
+	ExternalId string
+}
+
+func (q *Queries) UpsertArtistExternalID(ctx context.Context, arg UpsertArtistExternalIDParams) error {
+	_, err := q.db.ExecContext(ctx, upsertArtistExternalID, arg.ArtistUrl, arg.Service, arg.ExternalId)
+	return err
+}
+
+const upsertPageDiagnostics = `-- name: UpsertPageDiagnostics :exec
+INSERT INTO "PageDiagnostics" ("pageURL", "nameStrategy", "bioStrategy", "albumMisses", "skippedRows", "diagnostics", "recordedAt")
+  VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)
+ON CONFLICT ("pageURL")
+  DO UPDATE SET
+    "nameStrategy" = excluded."nameStrategy", "bioStrategy" = excluded."bioStrategy",
+      "albumMisses" = excluded."albumMisses", "skippedRows" = excluded."skippedRows",
+      "diagnostics" = excluded."diagnostics", "recordedAt" = excluded."recordedAt"
+`
+
+type UpsertPageDiagnosticsParams struct {
+	PageURL      string
+	NameStrategy sql.NullString
+	BioStrategy  sql.NullString
+	AlbumMisses  int64
+	SkippedRows  int64
+	Diagnostics  string
+	RecordedAt   time.Time
+}
+
+func (q *Queries) UpsertPageDiagnostics(ctx context.Context, arg UpsertPageDiagnosticsParams) error {
+	_, err := q.db.ExecContext(ctx, upsertPageDiagnostics,
+		arg.PageURL,
+		arg.NameStrategy,
+		arg.BioStrategy,
+		arg.AlbumMisses,
+		arg.SkippedRows,
+		arg.Diagnostics,
+		arg.RecordedAt,
+	)
+	return err
+}
+
+const upsertPageParse = `-- name: UpsertPageParse :exec
+INSERT INTO "PageParse" ("pageURL", "albums", "bioLength", "links", "parsedAt")
+  VALUES (?1, ?2, ?3, ?4, ?5)
+ON CONFLICT ("pageURL")
+  DO UPDATE SET
+    "albums" = excluded."albums", "bioLength" = excluded."bioLength",
+      "links" = excluded."links", "parsedAt" = excluded."parsedAt"
+`
+
+type UpsertPageParseParams struct {
+	PageURL   string
+	Albums    sql.NullInt64
+	BioLength sql.NullInt64
+	Links     sql.NullInt64
+	ParsedAt  time.Time
+}
+
+func (q *Queries) UpsertPageParse(ctx context.Context, arg UpsertPageParseParams) error {
+	_, err := q.db.ExecContext(ctx, upsertPageParse,
+		arg.PageURL,
+		arg.Albums,
+		arg.BioLength,
+		arg.Links,
+		arg.ParsedAt,
+	)
+	return err
+}
+
+const upsertPageQuarantine = `-- name: UpsertPageQuarantine :exec
+INSERT INTO "PageQuarantine" ("pageURL", "reason", "quarantinedAt")
   VALUES (?1, ?2, ?3)
 ON CONFLICT ("pageURL")
   DO UPDATE SET
-    "hash" = excluded."hash", "checkedOn" = excluded."checkedOn"
+    "reason" = excluded."reason", "quarantinedAt" = excluded."quarantinedAt"
+`
+
+type UpsertPageQuarantineParams struct {
+	PageURL       string
+	Reason        string
+	QuarantinedAt time.Time
+}
+
+func (q *Queries) UpsertPageQuarantine(ctx context.Context, arg UpsertPageQuarantineParams) error {
+	_, err := q.db.ExecContext(ctx, upsertPageQuarantine, arg.PageURL, arg.Reason, arg.QuarantinedAt)
+	return err
+}
+
+const upsertPageVersion = `-- name: UpsertPageVersion :exec
+INSERT INTO "PageVersion" ("pageURL", "hash", "firstSeen", "lastSeen")
+  VALUES (?1, ?2, ?3, ?3)
+ON CONFLICT ("pageURL", "hash")
+  DO UPDATE SET
+    "lastSeen" = excluded."lastSeen"
+`
+
+type UpsertPageVersionParams struct {
+	PageURL string
+	Hash    string
+	SeenOn  time.Time
+}
+
+func (q *Queries) UpsertPageVersion(ctx context.Context, arg UpsertPageVersionParams) error {
+	_, err := q.db.ExecContext(ctx, upsertPageVersion, arg.PageURL, arg.Hash, arg.SeenOn)
+	return err
+}
+
+const upsertRanking = `-- name: UpsertRanking :exec
+INSERT INTO "Ranking" ("pageURL", "title", "lastModified")
+  VALUES (?1, ?2, DATETIME('now'))
+ON CONFLICT ("pageURL")
+  DO UPDATE SET
+    "title" = excluded."title", "lastModified" = excluded."lastModified"
+`
+
+type UpsertRankingParams struct {
+	PageURL string
+	Title   string
+}
+
+func (q *Queries) UpsertRanking(ctx context.Context, arg UpsertRankingParams) error {
+	_, err := q.db.ExecContext(ctx, upsertRanking, arg.PageURL, arg.Title)
+	return err
+}
+
+const upsertUpdateHistory = `-- name: UpsertUpdateHistory :one
+INSERT INTO "UpdateHistory" ("checkedOn", "hash", "pageURL", "snapshotHash", "etag", "lastModified")
+  VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+ON CONFLICT ("pageURL")
+  DO UPDATE SET
+    "hash" = excluded."hash", "checkedOn" = excluded."checkedOn",
+      "snapshotHash" = excluded."snapshotHash", "etag" = excluded."etag",
+      "lastModified" = excluded."lastModified"
   WHERE
     excluded."hash" != "UpdateHistory"."hash"
   RETURNING
-    "checkedOn", "hash", "pageURL"
+    "checkedOn", "hash", "pageURL", "snapshotHash", "etag", "lastModified", "contentHash"
 `
 
 type UpsertUpdateHistoryParams struct {
-	CheckedOn time.Time
-	Hash      string
-	PageURL   string
+	CheckedOn    time.Time
+	Hash         string
+	PageURL      string
+	SnapshotHash sql.NullString
+	Etag         sql.NullString
+	LastModified sql.NullTime
 }
 
 func (q *Queries) UpsertUpdateHistory(ctx context.Context, arg UpsertUpdateHistoryParams) (UpdateHistory, error) {
-	row := q.db.QueryRowContext(ctx, upsertUpdateHistory, arg.CheckedOn, arg.Hash, arg.PageURL)
+	row := q.db.QueryRowContext(ctx, upsertUpdateHistory,
+		arg.CheckedOn,
+		arg.Hash,
+		arg.PageURL,
+		arg.SnapshotHash,
+		arg.Etag,
+		arg.LastModified,
+	)
 	var i UpdateHistory
-	err := row.Scan(&i.CheckedOn, &i.Hash, &i.PageURL)
+	err := row.Scan(
+		&i.CheckedOn,
+		&i.Hash,
+		&i.PageURL,
+		&i.SnapshotHash,
+		&i.Etag,
+		&i.LastModified,
+		&i.ContentHash,
+	)
 	return i, err
 }
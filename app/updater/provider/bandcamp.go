@@ -0,0 +1,338 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/waelbendhia/scruffy/app/updater/logging"
+	"github.com/waelbendhia/scruffy/app/updater/rate"
+	"go.uber.org/zap"
+)
+
+var _ interface {
+	AlbumProvider
+	ArtistProvider
+} = (*BandcampProvider)(nil)
+
+const bandcampSearchURL = "https://bandcamp.com/search"
+
+// bandcampAlbumPageLookups is the number of album results whose page is
+// fetched for their full size cover and release date.
+const bandcampAlbumPageLookups = 2
+
+// bandcampImageSize matches the size suffix of Bandcamp images, e.g.
+// ".../a1234567890_7.jpg". Size 10 is the original upload.
+var bandcampImageSize = regexp.MustCompile(`_[0-9]+\.(jpg|jpeg|png)$`)
+
+// errBandcampBusy is returned when the deadline passes while waiting on the
+// rate limit, with the default limit most lookups queue behind others.
+var errBandcampBusy = errors.New("no Bandcamp request before deadline")
+
+type (
+	BandcampOption   func(*BandcampProvider)
+	BandcampProvider struct {
+		artist disableable
+		album  disableable
+		client *http.Client
+		limit  *rate.Limiter
+	}
+	// BandcampSearchResult is an album or artist listed on a search page.
+	BandcampSearchResult struct {
+		URL      string
+		Name     string
+		Artist   string
+		ImageURL string
+		Released time.Time
+	}
+	// BandcampAlbumPage is what is read from an album's page.
+	BandcampAlbumPage struct {
+		Name     string
+		Artist   string
+		CoverURL string
+		Released time.Time
+	}
+)
+
+func (bp *BandcampProvider) ArtistDisable()      { bp.artist.disable() }
+func (bp *BandcampProvider) ArtistEnable()       { bp.artist.enable() }
+func (bp *BandcampProvider) ArtistEnabled() bool { return bp.artist.enabled() }
+
+func (bp *BandcampProvider) AlbumDisable()      { bp.album.disable() }
+func (bp *BandcampProvider) AlbumEnable()       { bp.album.enable() }
+func (bp *BandcampProvider) AlbumEnabled() bool { return bp.album.enabled() }
+
+func (*BandcampProvider) Name() string { return "bandcamp" }
+
+func BandcampWithClient(client *http.Client) BandcampOption {
+	return func(bp *BandcampProvider) { bp.client = client }
+}
+
+func BandcampWithRateLimiter(l *rate.Limiter) BandcampOption {
+	return func(bp *BandcampProvider) { bp.limit = l }
+}
+
+// NewBandcampProvider returns a provider that reads Bandcamp's search and
+// album pages. Bandcamp has no public API, the default rate limit is kept low
+// so that the pages are not hammered.
+func NewBandcampProvider(opts ...BandcampOption) *BandcampProvider {
+	bp := &BandcampProvider{}
+	for _, opt := range opts {
+		opt(bp)
+	}
+
+	if bp.client == nil {
+		bp.client = &http.Client{}
+	}
+
+	if bp.limit == nil {
+		bp.limit = rate.NewLimiter(1, 2*time.Second)
+	}
+
+	return bp
+}
+
+// bandcampImage rewrites an image URL to its original size.
+func bandcampImage(u string) string {
+	if u == "" {
+		return ""
+	}
+	return bandcampImageSize.ReplaceAllString(u, "_10.$1")
+}
+
+// parseBandcampDate parses the dates found on search and album pages, e.g.
+// "released March 3, 2017" or "03 Mar 2017 00:00:00 GMT".
+func parseBandcampDate(s string) time.Time {
+	s = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), "released"))
+	for _, layout := range []string{
+		"January 2, 2006",
+		"02 Jan 2006 15:04:05 GMT",
+		"2 January 2006",
+		"20060102",
+	} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func normalizeSpace(s string) string { return strings.Join(strings.Fields(s), " ") }
+
+// readBandcampSearchPage reads the results listed on a search page.
+func readBandcampSearchPage(doc *goquery.Document) []BandcampSearchResult {
+	var rs []BandcampSearchResult
+
+	doc.Find("li.searchresult").Each(func(_ int, li *goquery.Selection) {
+		link := li.Find(".result-info .heading a").First()
+		href, _, _ := strings.Cut(link.AttrOr("href", ""), "?")
+		name := normalizeSpace(link.Text())
+		if href == "" || name == "" {
+			return
+		}
+
+		r := BandcampSearchResult{
+			URL:      href,
+			Name:     name,
+			ImageURL: bandcampImage(li.Find(".art img").AttrOr("src", "")),
+			Released: parseBandcampDate(li.Find(".result-info .released").Text()),
+		}
+
+		if by, ok := strings.CutPrefix(normalizeSpace(li.Find(".result-info .subhead").Text()), "by "); ok {
+			r.Artist = by
+		}
+
+		rs = append(rs, r)
+	})
+
+	return rs
+}
+
+// readBandcampAlbumPage reads an album's page, the album's structured data is
+// preferred to its markup.
+func readBandcampAlbumPage(doc *goquery.Document) BandcampAlbumPage {
+	var (
+		page BandcampAlbumPage
+		ld   struct {
+			Name          string `json:"name"`
+			Image         string `json:"image"`
+			DatePublished string `json:"datePublished"`
+			ByArtist      struct {
+				Name string `json:"name"`
+			} `json:"byArtist"`
+		}
+	)
+
+	if err := json.Unmarshal(
+		[]byte(doc.Find(`script[type="application/ld+json"]`).First().Text()), &ld,
+	); err == nil {
+		page = BandcampAlbumPage{
+			Name:     ld.Name,
+			Artist:   ld.ByArtist.Name,
+			CoverURL: bandcampImage(ld.Image),
+			Released: parseBandcampDate(ld.DatePublished),
+		}
+	}
+
+	if page.Name == "" {
+		page.Name = normalizeSpace(doc.Find("#name-section .trackTitle").First().Text())
+	}
+	if page.Artist == "" {
+		page.Artist = normalizeSpace(doc.Find("#name-section h3 a").First().Text())
+	}
+	if page.CoverURL == "" {
+		page.CoverURL = bandcampImage(doc.Find(`meta[property="og:image"]`).AttrOr("content", ""))
+	}
+	if page.Released.IsZero() {
+		doc.Find(".tralbum-credits").Contents().EachWithBreak(func(_ int, s *goquery.Selection) bool {
+			page.Released = parseBandcampDate(s.Text())
+			return page.Released.IsZero()
+		})
+	}
+
+	return page
+}
+
+func (bp *BandcampProvider) getPage(
+	ctx context.Context, u string, params map[string]string,
+) (*goquery.Document, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+
+	q := req.URL.Query()
+	for k, v := range params {
+		q.Add(k, v)
+	}
+	req.URL.RawQuery = q.Encode()
+
+	if err := bp.limit.Do(ctx); errors.Is(err, context.DeadlineExceeded) {
+		return nil, errBandcampBusy
+	} else if err != nil {
+		return nil, err
+	}
+
+	resp, err := bp.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unhandled response status %d", resp.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse page: %w", err)
+	}
+
+	return doc, nil
+}
+
+// SearchAlbums implements AlbumProvider. The pages of the best matches are
+// read one at a time to stay within the rate limit. A search that does not get
+// its turn before the deadline finds nothing, as do page lookups.
+func (bp *BandcampProvider) SearchAlbums(
+	ctx context.Context, artist string, album string,
+) ([]AlbumResult, error) {
+	if !bp.album.enabled() {
+		return nil, ErrDisabled
+	}
+
+	doc, err := bp.getPage(ctx, bandcampSearchURL, map[string]string{
+		"q":         fmt.Sprintf("%s %s", artist, album),
+		"item_type": "a",
+	})
+	if errors.Is(err, errBandcampBusy) {
+		logging.GetLogger(ctx).Debug("skipping Bandcamp album search, rate limited")
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	rs := readBandcampSearchPage(doc)
+	as := make([]AlbumResult, 0, len(rs))
+	for i, r := range rs {
+		a := AlbumResult{
+			ID:          r.URL,
+			ArtistName:  r.Artist,
+			Name:        r.Name,
+			CoverURL:    r.ImageURL,
+			ReleaseYear: r.Released.Year(),
+			Confidence:  max(0, 100-i),
+		}
+		if r.Released.IsZero() {
+			a.ReleaseYear = 0
+		}
+
+		if i < bandcampAlbumPageLookups && ctx.Err() == nil {
+			doc, err := bp.getPage(ctx, r.URL, nil)
+			if errors.Is(err, errBandcampBusy) {
+				logging.GetLogger(ctx).With(zap.String("url", r.URL)).
+					Debug("skipping Bandcamp album page, rate limited")
+			} else if err != nil {
+				logging.GetLogger(ctx).With(zap.Error(err), zap.String("url", r.URL)).
+					Warn("could not get Bandcamp album page")
+			} else {
+				page := readBandcampAlbumPage(doc)
+				if page.Artist != "" {
+					a.ArtistName = page.Artist
+				}
+				if page.CoverURL != "" {
+					a.CoverURL = page.CoverURL
+				}
+				if !page.Released.IsZero() {
+					a.ReleaseYear = page.Released.Year()
+				}
+			}
+		}
+
+		as = append(as, a)
+	}
+
+	return as, nil
+}
+
+// SearchArtists implements ArtistProvider. A search that does not get its turn
+// before the deadline finds nothing.
+func (bp *BandcampProvider) SearchArtists(
+	ctx context.Context, artist string,
+) ([]ArtistResult, error) {
+	if !bp.artist.enabled() {
+		return nil, ErrDisabled
+	}
+
+	doc, err := bp.getPage(ctx, bandcampSearchURL, map[string]string{
+		"q":         artist,
+		"item_type": "b",
+	})
+	if errors.Is(err, errBandcampBusy) {
+		logging.GetLogger(ctx).Debug("skipping Bandcamp artist search, rate limited")
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	rs := readBandcampSearchPage(doc)
+	as := make([]ArtistResult, 0, len(rs))
+	for i, r := range rs {
+		as = append(as, ArtistResult{
+			ID:         r.URL,
+			Name:       r.Name,
+			ImageURL:   r.ImageURL,
+			Confidence: max(0, 100-i),
+		})
+	}
+
+	return as, nil
+}
@@ -0,0 +1,156 @@
+package provider_test
+
+import (
+	"context"
+	_ "embed"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/waelbendhia/scruffy/app/updater/provider"
+	"github.com/waelbendhia/scruffy/app/updater/rate"
+)
+
+//go:embed bandcamp-pages/search-albums.html
+var bandcampSearchAlbums []byte
+
+//go:embed bandcamp-pages/search-artists.html
+var bandcampSearchArtists []byte
+
+//go:embed bandcamp-pages/album-stateless.html
+var bandcampAlbumStateless []byte
+
+//go:embed bandcamp-pages/album-stateless-live.html
+var bandcampAlbumStatelessLive []byte
+
+// newBandcampProvider returns a provider that is not rate limited unless opts
+// say otherwise.
+func newBandcampProvider(t *testing.T, opts ...provider.BandcampOption) *provider.BandcampProvider {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search":
+			switch r.URL.Query().Get("item_type") {
+			case "a":
+				w.Write(bandcampSearchAlbums)
+			case "b":
+				w.Write(bandcampSearchArtists)
+			default:
+				t.Errorf("unexpected item type '%s'", r.URL.Query().Get("item_type"))
+				http.NotFound(w, r)
+			}
+		case "/album/stateless":
+			w.Write(bandcampAlbumStateless)
+		case "/album/stateless-live":
+			w.Write(bandcampAlbumStatelessLive)
+		default:
+			t.Errorf("unexpected request to '%s'", r.URL)
+			http.NotFound(w, r)
+		}
+	})
+
+	return provider.NewBandcampProvider(append([]provider.BandcampOption{
+		provider.BandcampWithClient(client),
+		provider.BandcampWithRateLimiter(testLimiter()),
+	}, opts...)...)
+}
+
+func TestBandcampSearchAlbums(t *testing.T) {
+	bp := newBandcampProvider(t)
+	bp.AlbumEnable()
+
+	as, err := bp.SearchAlbums(context.Background(), "Tashi Dorji", "Stateless")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first two results are read from their pages, the first one's page
+	// has the original release date rather than the reissue's.
+	expected := []provider.AlbumResult{
+		{
+			ID:          "https://tashidorji.bandcamp.com/album/stateless",
+			ArtistName:  "Tashi Dorji",
+			Name:        "Stateless",
+			CoverURL:    "https://f4.bcbits.com/img/a1839210441_10.jpg",
+			ReleaseYear: 2020,
+			Confidence:  100,
+		},
+		{
+			ID:          "https://hermitcrabrecords.bandcamp.com/album/stateless-live",
+			ArtistName:  "Tashi Dorji & Tyler Damon",
+			Name:        "Stateless (Live)",
+			CoverURL:    "https://f4.bcbits.com/img/a0095720113_10.jpg",
+			ReleaseYear: 2017,
+			Confidence:  99,
+		},
+		{
+			ID:         "https://tashidorji.bandcamp.com/album/appa",
+			ArtistName: "Tashi Dorji",
+			Name:       "Appa",
+			CoverURL:   "https://f4.bcbits.com/img/a0402118334_10.jpg",
+			Confidence: 98,
+		},
+	}
+	if !reflect.DeepEqual(as, expected) {
+		t.Errorf("expected %+v got %+v", expected, as)
+	}
+}
+
+func TestBandcampSearchArtists(t *testing.T) {
+	bp := newBandcampProvider(t)
+	bp.ArtistEnable()
+
+	as, err := bp.SearchArtists(context.Background(), "Tashi Dorji")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []provider.ArtistResult{
+		{
+			ID:         "https://tashidorji.bandcamp.com",
+			Name:       "Tashi Dorji",
+			ImageURL:   "https://f4.bcbits.com/img/0031128372_10.jpg",
+			Confidence: 100,
+		},
+		{
+			ID:         "https://tashidorjitylerdamon.bandcamp.com",
+			Name:       "Tashi Dorji & Tyler Damon",
+			Confidence: 99,
+		},
+	}
+	if !reflect.DeepEqual(as, expected) {
+		t.Errorf("expected %+v got %+v", expected, as)
+	}
+}
+
+func TestBandcampRateLimited(t *testing.T) {
+	// The album search and one of its pages fit in the limit.
+	bp := newBandcampProvider(t, provider.BandcampWithRateLimiter(rate.NewLimiter(2, time.Hour)))
+	bp.AlbumEnable()
+	bp.ArtistEnable()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	as, err := bp.SearchAlbums(ctx, "Tashi Dorji", "Stateless")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second album's page waits until the deadline and is skipped.
+	if len(as) != 3 {
+		t.Fatalf("expected 3 albums got %+v", as)
+	}
+	if as[0].ReleaseYear != 2020 {
+		t.Errorf("expected the first album's page to be read got %+v", as[0])
+	}
+
+	// Nothing is left for the artist search, it is a miss.
+	artists, err := bp.SearchArtists(ctx, "Tashi Dorji")
+	if err != nil {
+		t.Fatalf("expected rate limited search not to fail got '%v'", err)
+	}
+	if len(artists) != 0 {
+		t.Errorf("expected no artists got %+v", artists)
+	}
+}
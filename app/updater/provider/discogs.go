@@ -0,0 +1,400 @@
+package provider
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/waelbendhia/scruffy/app/updater/logging"
+	"github.com/waelbendhia/scruffy/app/updater/rate"
+	"go.uber.org/zap"
+)
+
+var _ interface {
+	AlbumProvider
+	ArtistProvider
+} = (*DiscogsProvider)(nil)
+
+const discogsBaseURL = "https://api.discogs.com"
+
+// discogsMasterLookups is the number of masters looked up for releases whose
+// master was not in the search results.
+const discogsMasterLookups = 3
+
+type (
+	DiscogsOption   func(*DiscogsProvider)
+	DiscogsProvider struct {
+		artist disableable
+		album  disableable
+		token  string
+		client *http.Client
+		limit  *rate.Limiter
+	}
+	DiscogsSearchItem struct {
+		ID         int      `json:"id"`
+		Type       string   `json:"type"`
+		MasterID   int      `json:"master_id"`
+		Title      string   `json:"title"`
+		Year       string   `json:"year"`
+		CoverImage string   `json:"cover_image"`
+		Thumb      string   `json:"thumb"`
+		Label      []string `json:"label"`
+	}
+	DiscogsSearchResult struct {
+		Results []DiscogsSearchItem `json:"results"`
+	}
+	DiscogsImage struct {
+		Type   string `json:"type"`
+		URI    string `json:"uri"`
+		Width  int    `json:"width"`
+		Height int    `json:"height"`
+	}
+	DiscogsMaster struct {
+		ID      int            `json:"id"`
+		Title   string         `json:"title"`
+		Year    int            `json:"year"`
+		Images  []DiscogsImage `json:"images"`
+		Artists []struct {
+			Name string `json:"name"`
+		} `json:"artists"`
+	}
+)
+
+func (dp *DiscogsProvider) ArtistDisable()      { dp.artist.disable() }
+func (dp *DiscogsProvider) ArtistEnable()       { dp.artist.enable() }
+func (dp *DiscogsProvider) ArtistEnabled() bool { return dp.artist.enabled() }
+
+func (dp *DiscogsProvider) AlbumDisable()      { dp.album.disable() }
+func (dp *DiscogsProvider) AlbumEnable()       { dp.album.enable() }
+func (dp *DiscogsProvider) AlbumEnabled() bool { return dp.album.enabled() }
+
+func (*DiscogsProvider) Name() string { return "discogs" }
+
+func DiscogsWithToken(token string) DiscogsOption {
+	return func(dp *DiscogsProvider) { dp.token = token }
+}
+
+func DiscogsWithClient(client *http.Client) DiscogsOption {
+	return func(dp *DiscogsProvider) { dp.client = client }
+}
+
+func DiscogsWithRateLimiter(l *rate.Limiter) DiscogsOption {
+	return func(dp *DiscogsProvider) { dp.limit = l }
+}
+
+// NewDiscogsProvider returns a Discogs provider, the personal access token
+// the database search requires is read from DISCOGS_TOKEN unless one is
+// given.
+func NewDiscogsProvider(opts ...DiscogsOption) *DiscogsProvider {
+	dp := &DiscogsProvider{}
+	for _, opt := range opts {
+		opt(dp)
+	}
+
+	if dp.token == "" {
+		dp.token = os.Getenv("DISCOGS_TOKEN")
+	}
+
+	if dp.client == nil {
+		dp.client = &http.Client{}
+	}
+
+	if dp.limit == nil {
+		dp.limit = rate.NewLimiter(60, time.Minute)
+	}
+
+	return dp
+}
+
+// discogsImage returns the first of urls that is neither empty nor the spacer
+// Discogs returns for items without images.
+func discogsImage(urls ...string) string {
+	for _, u := range urls {
+		if u != "" && !strings.HasSuffix(u, "spacer.gif") {
+			return u
+		}
+	}
+	return ""
+}
+
+// split returns the artist and title of a release or master, Discogs titles
+// them "Artist - Title".
+func (i *DiscogsSearchItem) split() (string, string) {
+	artist, title, ok := strings.Cut(i.Title, " - ")
+	if !ok {
+		return "", i.Title
+	}
+	return artist, title
+}
+
+func (i *DiscogsSearchItem) year() int {
+	y, _ := strconv.Atoi(i.Year)
+	return y
+}
+
+// label returns the first of the labels the release or master was released
+// on.
+func (i *DiscogsSearchItem) label() string {
+	for _, l := range i.Label {
+		if l != "" {
+			return l
+		}
+	}
+	return ""
+}
+
+func (i *DiscogsSearchItem) image() string {
+	return discogsImage(i.CoverImage, i.Thumb)
+}
+
+func (m *DiscogsMaster) image() string {
+	if len(m.Images) == 0 {
+		return ""
+	}
+
+	// Primary images are the front covers.
+	isPrimary := func(img DiscogsImage) int {
+		if img.Type == "primary" {
+			return 1
+		}
+		return 0
+	}
+
+	best := slices.MaxFunc(m.Images, func(a, b DiscogsImage) int {
+		if c := cmp.Compare(isPrimary(a), isPrimary(b)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Width*a.Height, b.Width*b.Height)
+	})
+	return discogsImage(best.URI)
+}
+
+func (m *DiscogsMaster) artist() string {
+	names := make([]string, 0, len(m.Artists))
+	for _, a := range m.Artists {
+		names = append(names, a.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func (dp *DiscogsProvider) get(
+	ctx context.Context, path string, params map[string]string,
+) (*http.Response, error) {
+	if dp.token == "" {
+		return nil, ErrMissingAPIKey
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", discogsBaseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Authorization", "Discogs token="+dp.token)
+
+	q := req.URL.Query()
+	for k, v := range params {
+		q.Add(k, v)
+	}
+	req.URL.RawQuery = q.Encode()
+
+	if err := dp.limit.Do(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := dp.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	return resp, nil
+}
+
+func (dp *DiscogsProvider) search(
+	ctx context.Context, params map[string]string,
+) ([]DiscogsSearchItem, error) {
+	resp, err := dp.get(ctx, "/database/search", params)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := readRespJSON[DiscogsSearchResult](resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Results, nil
+}
+
+func (dp *DiscogsProvider) getMaster(ctx context.Context, id int) (*DiscogsMaster, error) {
+	resp, err := dp.get(ctx, fmt.Sprintf("/masters/%d", id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return readRespJSON[DiscogsMaster](resp)
+}
+
+// SearchAlbums implements AlbumProvider. Releases are folded into their
+// master so that results carry the year of the original release rather than
+// that of a reissue. Results are ordered by the first of their releases in the
+// search results. The label is the master's first label or, when it has none,
+// that of its best placed release.
+func (dp *DiscogsProvider) SearchAlbums(
+	ctx context.Context, artist string, album string,
+) ([]AlbumResult, error) {
+	if !dp.album.enabled() {
+		return nil, ErrDisabled
+	}
+
+	items, err := dp.search(ctx, map[string]string{
+		"artist":        artist,
+		"release_title": album,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	type ixedResult struct {
+		i int
+		AlbumResult
+	}
+
+	var (
+		results []ixedResult
+		// masterIxs is the index in results of each master in the results.
+		masterIxs = map[int]int{}
+		// orphans are the releases of masters that were not in the results.
+		orphans = map[int][]ixedResult{}
+		missing []int
+	)
+
+	for i, it := range items {
+		if it.Type == "master" {
+			artistName, title := it.split()
+			masterIxs[it.ID] = len(results)
+			results = append(results, ixedResult{i: i, AlbumResult: AlbumResult{
+				ID:          "master/" + strconv.Itoa(it.ID),
+				ArtistName:  artistName,
+				Name:        title,
+				CoverURL:    it.image(),
+				ReleaseYear: it.year(),
+				Label:       it.label(),
+			}})
+		}
+	}
+
+	for i, it := range items {
+		if it.Type != "release" {
+			continue
+		}
+
+		if ix, ok := masterIxs[it.MasterID]; ok {
+			m := &results[ix]
+			m.i = min(m.i, i)
+			m.CoverURL = discogsImage(m.CoverURL, it.image())
+			if m.ReleaseYear == 0 {
+				m.ReleaseYear = it.year()
+			}
+			if m.Label == "" {
+				m.Label = it.label()
+			}
+			continue
+		}
+
+		artistName, title := it.split()
+		rel := ixedResult{i: i, AlbumResult: AlbumResult{
+			ID:          "release/" + strconv.Itoa(it.ID),
+			ArtistName:  artistName,
+			Name:        title,
+			CoverURL:    it.image(),
+			ReleaseYear: it.year(),
+			Label:       it.label(),
+		}}
+
+		_, isOrphan := orphans[it.MasterID]
+		switch {
+		case isOrphan:
+			orphans[it.MasterID] = append(orphans[it.MasterID], rel)
+		case it.MasterID != 0 && len(missing) < discogsMasterLookups:
+			missing = append(missing, it.MasterID)
+			orphans[it.MasterID] = []ixedResult{rel}
+		default:
+			results = append(results, rel)
+		}
+	}
+
+	for _, id := range missing {
+		m, err := dp.getMaster(ctx, id)
+		if err != nil {
+			logging.GetLogger(ctx).With(zap.Error(err), zap.Int("master-id", id)).
+				Warn("could not get Discogs master")
+			results = append(results, orphans[id]...)
+			continue
+		}
+
+		cover, label := m.image(), ""
+		for _, rel := range orphans[id] {
+			cover = discogsImage(cover, rel.CoverURL)
+			if label == "" {
+				label = rel.Label
+			}
+		}
+
+		// Orphans are appended in the order of the search results, the first
+		// one is the master's best placed release.
+		results = append(results, ixedResult{i: orphans[id][0].i, AlbumResult: AlbumResult{
+			ID:          "master/" + strconv.Itoa(m.ID),
+			ArtistName:  m.artist(),
+			Name:        m.Title,
+			CoverURL:    cover,
+			ReleaseYear: m.Year,
+			Label:       label,
+		}})
+	}
+
+	slices.SortStableFunc(results, func(a, b ixedResult) int { return cmp.Compare(a.i, b.i) })
+
+	as := make([]AlbumResult, 0, len(results))
+	for i, r := range results {
+		r.Confidence = max(0, 100-i)
+		as = append(as, r.AlbumResult)
+	}
+
+	return as, nil
+}
+
+// SearchArtists implements ArtistProvider.
+func (dp *DiscogsProvider) SearchArtists(
+	ctx context.Context, artist string,
+) ([]ArtistResult, error) {
+	if !dp.artist.enabled() {
+		return nil, ErrDisabled
+	}
+
+	items, err := dp.search(ctx, map[string]string{
+		"q":    artist,
+		"type": "artist",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	as := make([]ArtistResult, 0, len(items))
+	for i, it := range items {
+		as = append(as, ArtistResult{
+			ID:         strconv.Itoa(it.ID),
+			Name:       it.Title,
+			ImageURL:   it.image(),
+			Confidence: max(0, 100-i),
+		})
+	}
+
+	return as, nil
+}
@@ -0,0 +1,154 @@
+package provider_test
+
+import (
+	"context"
+	_ "embed"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/waelbendhia/scruffy/app/updater/provider"
+)
+
+//go:embed discogs-responses/search-albums.json
+var discogsSearchAlbums []byte
+
+//go:embed discogs-responses/search-artists.json
+var discogsSearchArtists []byte
+
+//go:embed discogs-responses/master-200.json
+var discogsMaster200 []byte
+
+//go:embed discogs-responses/master-400.json
+var discogsMaster400 []byte
+
+func newDiscogsProvider(t *testing.T) *provider.DiscogsProvider {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Discogs token=token" {
+			t.Errorf("unexpected authorization '%s'", auth)
+		}
+
+		switch r.URL.Path {
+		case "/database/search":
+			if r.URL.Query().Get("type") == "artist" {
+				w.Write(discogsSearchArtists)
+			} else {
+				w.Write(discogsSearchAlbums)
+			}
+		case "/masters/200":
+			w.Write(discogsMaster200)
+		case "/masters/300":
+			http.Error(w, `{"message": "Master Release not found."}`, http.StatusNotFound)
+		case "/masters/400":
+			w.Write(discogsMaster400)
+		default:
+			t.Errorf("unexpected request to '%s'", r.URL)
+			http.NotFound(w, r)
+		}
+	})
+
+	return provider.NewDiscogsProvider(
+		provider.DiscogsWithToken("token"),
+		provider.DiscogsWithClient(client),
+		provider.DiscogsWithRateLimiter(testLimiter()),
+	)
+}
+
+func TestDiscogsSearchAlbums(t *testing.T) {
+	dp := newDiscogsProvider(t)
+	dp.AlbumEnable()
+
+	as, err := dp.SearchAlbums(context.Background(), "Can", "Tago Mago")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The reissue is folded into its master, which takes its place and cover
+	// but keeps its own label. Masters that were looked up take the label of
+	// their best placed release that has one.
+	// The releases of masters that were not in the results are folded into
+	// the looked up master, unless the lookup fails or the lookups ran out.
+	expected := []provider.AlbumResult{
+		{
+			ID:          "master/100",
+			ArtistName:  "Can",
+			Name:        "Tago Mago",
+			CoverURL:    "https://i.discogs.com/r1001.jpg",
+			ReleaseYear: 1971,
+			Label:       "United Artists Records",
+			Confidence:  100,
+		},
+		{
+			ID:          "master/200",
+			ArtistName:  "Faust",
+			Name:        "Faust IV",
+			CoverURL:    "https://i.discogs.com/m200.jpg",
+			ReleaseYear: 1973,
+			Label:       "Polydor",
+			Confidence:  99,
+		},
+		{
+			ID:          "release/3001",
+			ArtistName:  "Neu!",
+			Name:        "Neu! 2",
+			CoverURL:    "https://i.discogs.com/r3001.jpg",
+			ReleaseYear: 1973,
+			Label:       "United Artists Records",
+			Confidence:  98,
+		},
+		{
+			ID:          "master/400",
+			ArtistName:  "Cluster",
+			Name:        "Zuckerzeit",
+			ReleaseYear: 1974,
+			Label:       "Sky Records",
+			Confidence:  97,
+		},
+		{
+			ID:          "release/5001",
+			ArtistName:  "Harmonia",
+			Name:        "Deluxe",
+			CoverURL:    "https://i.discogs.com/r5001.jpg",
+			ReleaseYear: 1975,
+			Label:       "Brain",
+			Confidence:  96,
+		},
+		{
+			ID:         "release/6001",
+			ArtistName: "Can",
+			Name:       "Tago Mago Outtakes",
+			CoverURL:   "https://i.discogs.com/r6001-thumb.jpg",
+			Confidence: 95,
+		},
+	}
+	if !reflect.DeepEqual(as, expected) {
+		t.Errorf("expected %+v got %+v", expected, as)
+	}
+}
+
+func TestDiscogsSearchArtists(t *testing.T) {
+	dp := newDiscogsProvider(t)
+	dp.ArtistEnable()
+
+	as, err := dp.SearchArtists(context.Background(), "Faust")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []provider.ArtistResult{
+		{
+			ID:         "18166",
+			Name:       "Faust",
+			ImageURL:   "https://i.discogs.com/a18166.jpg",
+			Confidence: 100,
+		},
+		{
+			ID:         "1234567",
+			Name:       "Faust (7)",
+			Confidence: 99,
+		},
+	}
+	if !reflect.DeepEqual(as, expected) {
+		t.Errorf("expected %+v got %+v", expected, as)
+	}
+}
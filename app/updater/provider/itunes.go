@@ -0,0 +1,290 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/waelbendhia/scruffy/app/updater/logging"
+	"github.com/waelbendhia/scruffy/app/updater/rate"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+var _ interface {
+	AlbumProvider
+	ArtistProvider
+} = (*ITunesProvider)(nil)
+
+const iTunesSearchURL = "https://itunes.apple.com/search"
+
+// iTunesArtistPageLookups is the number of artist results whose Apple Music
+// page is fetched for an image.
+const iTunesArtistPageLookups = 3
+
+// iTunesArtworkSize matches the size at the end of artwork URLs, e.g.
+// ".../100x100bb.jpg".
+var iTunesArtworkSize = regexp.MustCompile(`/[0-9]+x[0-9]+[a-z]*\.(jpg|jpeg|png|webp)$`)
+
+type (
+	ITunesOption   func(*ITunesProvider)
+	ITunesProvider struct {
+		artist disableable
+		album  disableable
+		client *http.Client
+		limit  *rate.Limiter
+		// pageLimit is the budget for Apple Music artist pages, which are not
+		// part of the API.
+		pageLimit *rate.Limiter
+	}
+	ITunesResult struct {
+		ArtistID       int    `json:"artistId"`
+		CollectionID   int    `json:"collectionId"`
+		ArtistName     string `json:"artistName"`
+		CollectionName string `json:"collectionName"`
+		ArtistLinkURL  string `json:"artistLinkUrl"`
+		ArtworkURL100  string `json:"artworkUrl100"`
+		ArtworkURL60   string `json:"artworkUrl60"`
+		ReleaseDate    string `json:"releaseDate"`
+	}
+	ITunesSearchResult struct {
+		ResultCount int            `json:"resultCount"`
+		Results     []ITunesResult `json:"results"`
+	}
+)
+
+func (ip *ITunesProvider) ArtistDisable()      { ip.artist.disable() }
+func (ip *ITunesProvider) ArtistEnable()       { ip.artist.enable() }
+func (ip *ITunesProvider) ArtistEnabled() bool { return ip.artist.enabled() }
+
+func (ip *ITunesProvider) AlbumDisable()      { ip.album.disable() }
+func (ip *ITunesProvider) AlbumEnable()       { ip.album.enable() }
+func (ip *ITunesProvider) AlbumEnabled() bool { return ip.album.enabled() }
+
+func (*ITunesProvider) Name() string { return "itunes" }
+
+func ITunesWithClient(client *http.Client) ITunesOption {
+	return func(ip *ITunesProvider) { ip.client = client }
+}
+
+func ITunesWithRateLimiter(l *rate.Limiter) ITunesOption {
+	return func(ip *ITunesProvider) { ip.limit = l }
+}
+
+func ITunesWithPageRateLimiter(l *rate.Limiter) ITunesOption {
+	return func(ip *ITunesProvider) { ip.pageLimit = l }
+}
+
+// NewITunesProvider returns a provider backed by the iTunes Search API, it
+// needs no credentials.
+func NewITunesProvider(opts ...ITunesOption) *ITunesProvider {
+	ip := &ITunesProvider{}
+	for _, opt := range opts {
+		opt(ip)
+	}
+
+	if ip.client == nil {
+		ip.client = &http.Client{}
+	}
+
+	if ip.limit == nil {
+		// The API allows roughly 20 requests a minute.
+		ip.limit = rate.NewLimiter(20, time.Minute)
+	}
+
+	if ip.pageLimit == nil {
+		ip.pageLimit = rate.NewLimiter(20, time.Minute)
+	}
+
+	return ip
+}
+
+// highResArtwork rewrites an artwork URL to its 1000x1000 version, the API
+// only returns thumbnails but serves any size from the same path.
+func highResArtwork(u string) string {
+	if u == "" {
+		return ""
+	}
+	return iTunesArtworkSize.ReplaceAllString(u, "/1000x1000bb.jpg")
+}
+
+func (r *ITunesResult) cover() string {
+	if r.ArtworkURL100 != "" {
+		return highResArtwork(r.ArtworkURL100)
+	}
+	return highResArtwork(r.ArtworkURL60)
+}
+
+func (r *ITunesResult) year() int {
+	if r.ReleaseDate == "" {
+		return 0
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, r.ReleaseDate); err == nil {
+			return t.Year()
+		}
+	}
+
+	return 0
+}
+
+func (ip *ITunesProvider) search(
+	ctx context.Context, params map[string]string,
+) ([]ITunesResult, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", iTunesSearchURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	q := req.URL.Query()
+	q.Add("media", "music")
+	q.Add("limit", "25")
+	for k, v := range params {
+		q.Add(k, v)
+	}
+	req.URL.RawQuery = q.Encode()
+
+	req.Header.Set("User-Agent", userAgent)
+
+	if err := ip.limit.Do(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := ip.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	res, err := readRespJSON[ITunesSearchResult](resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Results, nil
+}
+
+// artistImage returns the image Apple Music shows on the artist's page, the
+// search API does not return artist images.
+func (ip *ITunesProvider) artistImage(ctx context.Context, pageURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := ip.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unhandled response status %d", resp.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("could not parse artist page: %w", err)
+	}
+
+	img := doc.Find(`meta[property="og:image"]`).AttrOr("content", "")
+	return highResArtwork(img), nil
+}
+
+// SearchAlbums implements AlbumProvider.
+func (ip *ITunesProvider) SearchAlbums(
+	ctx context.Context, artist string, album string,
+) ([]AlbumResult, error) {
+	if !ip.album.enabled() {
+		return nil, ErrDisabled
+	}
+
+	rs, err := ip.search(ctx, map[string]string{
+		"term":   fmt.Sprintf("%s %s", artist, album),
+		"entity": "album",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	as := make([]AlbumResult, 0, len(rs))
+	for i, r := range rs {
+		as = append(as, AlbumResult{
+			ID:          strconv.Itoa(r.CollectionID),
+			ArtistName:  r.ArtistName,
+			Name:        r.CollectionName,
+			CoverURL:    r.cover(),
+			ReleaseYear: r.year(),
+			Confidence:  max(0, 100-i),
+		})
+	}
+
+	return as, nil
+}
+
+// SearchArtists implements ArtistProvider. Images are only looked up for the
+// best matches, and only while the page budget lasts so the search never waits
+// on them.
+func (ip *ITunesProvider) SearchArtists(
+	ctx context.Context, artist string,
+) ([]ArtistResult, error) {
+	if !ip.artist.enabled() {
+		return nil, ErrDisabled
+	}
+
+	rs, err := ip.search(ctx, map[string]string{
+		"term":   artist,
+		"entity": "musicArtist",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	as := make([]ArtistResult, len(rs))
+
+	g, gctx := errgroup.WithContext(ctx)
+	for i, r := range rs {
+		i, r := i, r
+		as[i] = ArtistResult{
+			ID:         strconv.Itoa(r.ArtistID),
+			Name:       r.ArtistName,
+			Confidence: max(0, 100-i),
+		}
+
+		if i >= iTunesArtistPageLookups || r.ArtistLinkURL == "" {
+			continue
+		}
+
+		if !ip.pageLimit.TryDo() {
+			logging.GetLogger(ctx).
+				With(zap.String("url", r.ArtistLinkURL)).
+				Debug("skipping Apple Music artist page, page budget spent")
+			continue
+		}
+
+		g.Go(func() error {
+			img, err := ip.artistImage(gctx, r.ArtistLinkURL)
+			if err != nil {
+				logging.GetLogger(gctx).
+					With(zap.Error(err), zap.String("url", r.ArtistLinkURL)).
+					Warn("could not get Apple Music artist image")
+				return nil
+			}
+
+			as[i].ImageURL = img
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return as, nil
+}
@@ -0,0 +1,174 @@
+package provider_test
+
+import (
+	"context"
+	_ "embed"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/waelbendhia/scruffy/app/updater/provider"
+	"github.com/waelbendhia/scruffy/app/updater/rate"
+)
+
+//go:embed itunes-responses/search-albums.json
+var iTunesSearchAlbums []byte
+
+//go:embed itunes-responses/search-artists.json
+var iTunesSearchArtists []byte
+
+//go:embed itunes-responses/artist-page.html
+var iTunesArtistPage []byte
+
+//go:embed itunes-responses/artist-page-no-image.html
+var iTunesArtistPageNoImage []byte
+
+// newITunesProvider returns a provider that is not rate limited unless opts
+// say otherwise, pages is incremented for every artist page that is fetched.
+func newITunesProvider(
+	t *testing.T, opts ...provider.ITunesOption,
+) (ip *provider.ITunesProvider, pages *atomic.Int32) {
+	pages = &atomic.Int32{}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search":
+			switch r.URL.Query().Get("entity") {
+			case "album":
+				w.Write(iTunesSearchAlbums)
+			case "musicArtist":
+				w.Write(iTunesSearchArtists)
+			default:
+				t.Errorf("unexpected entity '%s'", r.URL.Query().Get("entity"))
+				http.NotFound(w, r)
+			}
+			return
+		case "/us/artist/tim-buckley/3400786":
+			w.Write(iTunesArtistPage)
+		case "/us/artist/jeff-buckley/1226545":
+			w.Write(iTunesArtistPageNoImage)
+		case "/us/artist/tim-buckley-larry-beckett/1000002":
+			http.NotFound(w, r)
+		default:
+			t.Errorf("unexpected request to '%s'", r.URL)
+			http.NotFound(w, r)
+		}
+		pages.Add(1)
+	})
+
+	ip = provider.NewITunesProvider(append([]provider.ITunesOption{
+		provider.ITunesWithClient(client),
+		provider.ITunesWithRateLimiter(testLimiter()),
+		provider.ITunesWithPageRateLimiter(testLimiter()),
+	}, opts...)...)
+
+	return ip, pages
+}
+
+func TestITunesSearchAlbums(t *testing.T) {
+	ip, _ := newITunesProvider(t)
+	ip.AlbumEnable()
+
+	as, err := ip.SearchAlbums(context.Background(), "Tim Buckley", "Starsailor")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Artwork thumbnails are rewritten to their 1000x1000 version, artwork
+	// URLs without a size are kept as they are.
+	expected := []provider.AlbumResult{
+		{
+			ID:          "1440851491",
+			ArtistName:  "Tim Buckley",
+			Name:        "Starsailor",
+			CoverURL:    "https://is1-ssl.mzstatic.com/image/thumb/Music/v4/1a/2b/starsailor.jpg/1000x1000bb.jpg",
+			ReleaseYear: 1970,
+			Confidence:  100,
+		},
+		{
+			ID:          "1440851492",
+			ArtistName:  "Tim Buckley",
+			Name:        "Dream Letter: Live in London 1968",
+			CoverURL:    "https://is1-ssl.mzstatic.com/image/thumb/Music/v4/3c/4d/dream.png/1000x1000bb.jpg",
+			ReleaseYear: 1990,
+			Confidence:  99,
+		},
+		{
+			ID:         "1440851493",
+			ArtistName: "Tim Buckley",
+			Name:       "Starsailor (Demos)",
+			Confidence: 98,
+		},
+		{
+			ID:         "1440851494",
+			ArtistName: "Starsailor",
+			Name:       "Love Is Here",
+			CoverURL:   "https://is1-ssl.mzstatic.com/image/source/love-is-here",
+			Confidence: 97,
+		},
+	}
+	if !reflect.DeepEqual(as, expected) {
+		t.Errorf("expected %+v got %+v", expected, as)
+	}
+}
+
+func TestITunesSearchArtists(t *testing.T) {
+	ip, pages := newITunesProvider(t)
+	ip.ArtistEnable()
+
+	as, err := ip.SearchArtists(context.Background(), "Tim Buckley")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the first three artists' pages are fetched, pages without an
+	// image or that could not be fetched leave the artist without one.
+	expected := []provider.ArtistResult{
+		{
+			ID:         "3400786",
+			Name:       "Tim Buckley",
+			ImageURL:   "https://is1-ssl.mzstatic.com/image/thumb/Music/v4/5e/6f/buckley.jpg/1000x1000bb.jpg",
+			Confidence: 100,
+		},
+		{ID: "1226545", Name: "Jeff Buckley", Confidence: 99},
+		{ID: "1000002", Name: "Tim Buckley & Larry Beckett", Confidence: 98},
+		{ID: "1000003", Name: "Buckley Tribute Band", Confidence: 97},
+	}
+	if !reflect.DeepEqual(as, expected) {
+		t.Errorf("expected %+v got %+v", expected, as)
+	}
+
+	if n := pages.Load(); n != 3 {
+		t.Errorf("expected 3 artist pages to be fetched got %d", n)
+	}
+}
+
+func TestITunesArtistPagesRateLimit(t *testing.T) {
+	// Each search fits in the API's limit and a single artist page fits in
+	// the page budget, pages do not take from the API's limit.
+	ip, pages := newITunesProvider(
+		t,
+		provider.ITunesWithRateLimiter(rate.NewLimiter(2, time.Hour)),
+		provider.ITunesWithPageRateLimiter(rate.NewLimiter(1, time.Hour)),
+	)
+	ip.ArtistEnable()
+
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		as, err := ip.SearchArtists(ctx, "Tim Buckley")
+		if err != nil {
+			t.Fatalf("search %d: %v", i+1, err)
+		}
+
+		if len(as) != 4 {
+			t.Errorf("search %d: expected 4 artists got %d", i+1, len(as))
+		}
+	}
+
+	if n := pages.Load(); n != 1 {
+		t.Errorf("expected 1 artist page to be fetched got %d", n)
+	}
+}
@@ -2,21 +2,380 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/waelbendhia/scruffy/app/updater/logging"
+	"github.com/waelbendhia/scruffy/app/updater/rate"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 )
 
-var _ AlbumProvider = (*LastFMProvider)(nil)
+var _ interface {
+	AlbumProvider
+	ArtistProvider
+} = (*LastFMProvider)(nil)
+
+const lastFMBaseURL = "https://ws.audioscrobbler.com/2.0/"
+
+// lastFMPlaceholderImage is the star Last.fm serves for artists and albums
+// that have no image.
+const lastFMPlaceholderImage = "2a96cbd8b46e442fc41c2b86b821562f"
+
+// lastFMInfoLookups is the number of search results album.getInfo is called
+// for.
+const lastFMInfoLookups = 3
 
-type LastFMProvider struct{}
+var ErrMissingAPIKey = errors.New("missing API key")
 
-func (lfmp *LastFMProvider) AlbumDisable()      {}
-func (lfmp *LastFMProvider) AlbumEnable()       {}
-func (lfmp *LastFMProvider) AlbumEnabled() bool { return false }
+type (
+	LastFMOption   func(*LastFMProvider)
+	LastFMProvider struct {
+		artist disableable
+		album  disableable
+		apiKey string
+		client *http.Client
+		limit  *rate.Limiter
+	}
+	LastFMImage struct {
+		URL  string `json:"#text"`
+		Size string `json:"size"`
+	}
+	LastFMTag struct {
+		Name string `json:"name"`
+	}
+	LastFMAlbum struct {
+		Name   string        `json:"name"`
+		Artist string        `json:"artist"`
+		MBID   string        `json:"mbid"`
+		URL    string        `json:"url"`
+		Image  []LastFMImage `json:"image"`
+	}
+	// LastFMTags are an album's tags, Last.fm returns an empty string for
+	// albums without tags and an object for albums with a single tag.
+	LastFMTags      []LastFMTag
+	LastFMAlbumInfo struct {
+		LastFMAlbum
+		Tags LastFMTags `json:"tags"`
+	}
+	LastFMArtist struct {
+		Name  string        `json:"name"`
+		MBID  string        `json:"mbid"`
+		URL   string        `json:"url"`
+		Image []LastFMImage `json:"image"`
+	}
+	// LastFMResponse is embedded in every response, the API reports some
+	// errors with a 200.
+	LastFMResponse struct {
+		Error   int    `json:"error"`
+		Message string `json:"message"`
+	}
+	LastFMAlbumSearchResult struct {
+		LastFMResponse
+		Results struct {
+			AlbumMatches struct {
+				Album []LastFMAlbum `json:"album"`
+			} `json:"albummatches"`
+		} `json:"results"`
+	}
+	LastFMAlbumInfoResult struct {
+		LastFMResponse
+		Album LastFMAlbumInfo `json:"album"`
+	}
+	LastFMArtistSearchResult struct {
+		LastFMResponse
+		Results struct {
+			ArtistMatches struct {
+				Artist []LastFMArtist `json:"artist"`
+			} `json:"artistmatches"`
+		} `json:"results"`
+	}
+)
+
+func (lfmp *LastFMProvider) ArtistDisable()      { lfmp.artist.disable() }
+func (lfmp *LastFMProvider) ArtistEnable()       { lfmp.artist.enable() }
+func (lfmp *LastFMProvider) ArtistEnabled() bool { return lfmp.artist.enabled() }
+
+func (lfmp *LastFMProvider) AlbumDisable()      { lfmp.album.disable() }
+func (lfmp *LastFMProvider) AlbumEnable()       { lfmp.album.enable() }
+func (lfmp *LastFMProvider) AlbumEnabled() bool { return lfmp.album.enabled() }
 
 func (*LastFMProvider) Name() string { return "lastfm" }
 
-// SearchAlbums implements AlbumProvider.
-func (mb *LastFMProvider) SearchAlbums(
+func LastFMWithAPIKey(key string) LastFMOption {
+	return func(lfmp *LastFMProvider) { lfmp.apiKey = key }
+}
+
+func LastFMWithClient(client *http.Client) LastFMOption {
+	return func(lfmp *LastFMProvider) { lfmp.client = client }
+}
+
+func LastFMWithRateLimiter(l *rate.Limiter) LastFMOption {
+	return func(lfmp *LastFMProvider) { lfmp.limit = l }
+}
+
+// NewLastFMProvider returns a Last.fm provider, the API key is read from
+// LAST_FM_API_KEY unless one is given.
+func NewLastFMProvider(opts ...LastFMOption) *LastFMProvider {
+	lfmp := &LastFMProvider{}
+	for _, opt := range opts {
+		opt(lfmp)
+	}
+
+	if lfmp.apiKey == "" {
+		lfmp.apiKey = os.Getenv("LAST_FM_API_KEY")
+	}
+
+	if lfmp.client == nil {
+		lfmp.client = &http.Client{}
+	}
+
+	if lfmp.limit == nil {
+		lfmp.limit = rate.NewLimiter(5, time.Second)
+	}
+
+	return lfmp
+}
+
+func lastFMImage(images []LastFMImage) string {
+	best, bestRank := "", -1
+	for _, img := range images {
+		if img.URL == "" || strings.Contains(img.URL, lastFMPlaceholderImage) {
+			continue
+		}
+
+		rank := -1
+		for i, size := range []string{"small", "medium", "large", "extralarge", "mega"} {
+			if img.Size == size {
+				rank = i
+			}
+		}
+
+		if rank > bestRank || best == "" {
+			best, bestRank = img.URL, rank
+		}
+	}
+
+	return best
+}
+
+// year returns the release year found in the album's tags, Last.fm no longer
+// returns release dates but albums are commonly tagged with their year.
+func (a *LastFMAlbumInfo) year() int {
+	year := 0
+	for _, t := range a.Tags {
+		y, err := strconv.Atoi(strings.TrimSpace(t.Name))
+		if err != nil || y < 1900 || y > time.Now().Year() {
+			continue
+		}
+		if year == 0 || y < year {
+			year = y
+		}
+	}
+	return year
+}
+
+// lastFMID returns the MusicBrainz ID Last.fm has for an artist or album, or
+// its Last.fm URL when it has none.
+func lastFMID(mbid, url string) string {
+	if mbid != "" {
+		return mbid
+	}
+	return url
+}
+
+func (lfmp *LastFMProvider) get(
+	ctx context.Context, method string, params map[string]string,
+) (*http.Response, error) {
+	if lfmp.apiKey == "" {
+		return nil, ErrMissingAPIKey
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", lastFMBaseURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+
+	q := req.URL.Query()
+	q.Add("method", method)
+	q.Add("api_key", lfmp.apiKey)
+	q.Add("format", "json")
+	for k, v := range params {
+		q.Add(k, v)
+	}
+	req.URL.RawQuery = q.Encode()
+
+	if err := lfmp.limit.Do(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := lfmp.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	return resp, nil
+}
+
+func (ts *LastFMTags) UnmarshalJSON(data []byte) error {
+	var tags struct {
+		Tag json.RawMessage `json:"tag"`
+	}
+	if err := json.Unmarshal(data, &tags); err != nil || len(tags.Tag) == 0 {
+		*ts = nil
+		return nil
+	}
+
+	if err := json.Unmarshal(tags.Tag, (*[]LastFMTag)(ts)); err == nil {
+		return nil
+	}
+
+	var tag LastFMTag
+	if err := json.Unmarshal(tags.Tag, &tag); err != nil {
+		return err
+	}
+	*ts = LastFMTags{tag}
+	return nil
+}
+
+func (r LastFMResponse) err() error {
+	if r.Error == 0 {
+		return nil
+	}
+	return fmt.Errorf("last.fm error %d: %s", r.Error, r.Message)
+}
+
+func (lfmp *LastFMProvider) getAlbumInfo(
+	ctx context.Context, artist, album string,
+) (*LastFMAlbumInfo, error) {
+	resp, err := lfmp.get(ctx, "album.getinfo", map[string]string{
+		"artist":      artist,
+		"album":       album,
+		"autocorrect": "1",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := readRespJSON[LastFMAlbumInfoResult](resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := res.err(); err != nil {
+		return nil, err
+	}
+
+	return &res.Album, nil
+}
+
+// SearchAlbums implements AlbumProvider. The covers and years of the best
+// matches come from album.getInfo.
+func (lfmp *LastFMProvider) SearchAlbums(
 	ctx context.Context, artist string, album string,
 ) ([]AlbumResult, error) {
-	return nil, ErrDisabled
+	if !lfmp.album.enabled() {
+		return nil, ErrDisabled
+	}
+
+	resp, err := lfmp.get(ctx, "album.search", map[string]string{
+		"album": fmt.Sprintf("%s %s", artist, album),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := readRespJSON[LastFMAlbumSearchResult](resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := res.err(); err != nil {
+		return nil, err
+	}
+
+	matches := res.Results.AlbumMatches.Album
+	as := make([]AlbumResult, len(matches))
+
+	g, gctx := errgroup.WithContext(ctx)
+	for i, a := range matches {
+		i, a := i, a
+		as[i] = AlbumResult{
+			ID:         lastFMID(a.MBID, a.URL),
+			ArtistName: a.Artist,
+			Name:       a.Name,
+			CoverURL:   lastFMImage(a.Image),
+			Confidence: max(0, 100-i),
+		}
+
+		if i >= lastFMInfoLookups {
+			continue
+		}
+
+		g.Go(func() error {
+			info, err := lfmp.getAlbumInfo(gctx, a.Artist, a.Name)
+			if err != nil {
+				logging.GetLogger(gctx).
+					With(zap.Error(err), zap.String("album", a.Name)).
+					Warn("could not get Last.fm album info")
+				return nil
+			}
+
+			if cover := lastFMImage(info.Image); cover != "" {
+				as[i].CoverURL = cover
+			}
+			as[i].ReleaseYear = info.year()
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return as, nil
+}
+
+// SearchArtists implements ArtistProvider. Last.fm serves its placeholder
+// instead of most artists' images, so most results come without one.
+func (lfmp *LastFMProvider) SearchArtists(
+	ctx context.Context, artist string,
+) ([]ArtistResult, error) {
+	if !lfmp.artist.enabled() {
+		return nil, ErrDisabled
+	}
+
+	resp, err := lfmp.get(ctx, "artist.search", map[string]string{"artist": artist})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := readRespJSON[LastFMArtistSearchResult](resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := res.err(); err != nil {
+		return nil, err
+	}
+
+	as := make([]ArtistResult, 0, len(res.Results.ArtistMatches.Artist))
+	for i, a := range res.Results.ArtistMatches.Artist {
+		as = append(as, ArtistResult{
+			ID:         lastFMID(a.MBID, a.URL),
+			Name:       a.Name,
+			ImageURL:   lastFMImage(a.Image),
+			Confidence: max(0, 100-i),
+		})
+	}
+
+	return as, nil
 }
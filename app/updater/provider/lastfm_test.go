@@ -0,0 +1,197 @@
+package provider_test
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/waelbendhia/scruffy/app/updater/provider"
+)
+
+//go:embed lastfm-responses/album-search.json
+var lastFMAlbumSearch []byte
+
+//go:embed lastfm-responses/album-info-starsailor.json
+var lastFMAlbumInfoStarsailor []byte
+
+//go:embed lastfm-responses/album-info-remastered.json
+var lastFMAlbumInfoRemastered []byte
+
+//go:embed lastfm-responses/album-info-band.json
+var lastFMAlbumInfoBand []byte
+
+//go:embed lastfm-responses/artist-search.json
+var lastFMArtistSearch []byte
+
+//go:embed lastfm-responses/error.json
+var lastFMError []byte
+
+func newLastFMProvider(t *testing.T, apiKey string) *provider.LastFMProvider {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("api_key") != "valid" {
+			w.Write(lastFMError)
+			return
+		}
+
+		switch q.Get("method") {
+		case "album.search":
+			w.Write(lastFMAlbumSearch)
+		case "album.getinfo":
+			switch q.Get("artist") + " - " + q.Get("album") {
+			case "Tim Buckley - Starsailor":
+				w.Write(lastFMAlbumInfoStarsailor)
+			case "Tim Buckley - Starsailor (Remastered)":
+				w.Write(lastFMAlbumInfoRemastered)
+			case "Starsailor - Starsailor":
+				w.Write(lastFMAlbumInfoBand)
+			default:
+				t.Errorf("unexpected album info lookup '%s' '%s'", q.Get("artist"), q.Get("album"))
+				http.NotFound(w, r)
+			}
+		case "artist.search":
+			w.Write(lastFMArtistSearch)
+		default:
+			t.Errorf("unexpected method '%s'", q.Get("method"))
+			http.NotFound(w, r)
+		}
+	})
+
+	return provider.NewLastFMProvider(
+		provider.LastFMWithAPIKey(apiKey),
+		provider.LastFMWithClient(client),
+		provider.LastFMWithRateLimiter(testLimiter()),
+	)
+}
+
+func TestLastFMSearchAlbums(t *testing.T) {
+	lfmp := newLastFMProvider(t, "valid")
+	lfmp.AlbumEnable()
+
+	as, err := lfmp.SearchAlbums(context.Background(), "Tim Buckley", "Starsailor")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first three results are looked up with album.getInfo. Placeholder
+	// and empty images are skipped and the year is the earliest year tag.
+	expected := []provider.AlbumResult{
+		{
+			ID:          "a1b2c3d4-0000-4000-8000-000000000001",
+			ArtistName:  "Tim Buckley",
+			Name:        "Starsailor",
+			CoverURL:    "https://lastfm.freetls.fastly.net/i/u/starsailor.png",
+			ReleaseYear: 1970,
+			Confidence:  100,
+		},
+		{
+			ID:         "https://www.last.fm/music/Tim+Buckley/Starsailor+(Remastered)",
+			ArtistName: "Tim Buckley",
+			Name:       "Starsailor (Remastered)",
+			Confidence: 99,
+		},
+		{
+			ID:          "https://www.last.fm/music/Starsailor/Starsailor",
+			ArtistName:  "Starsailor",
+			Name:        "Starsailor",
+			CoverURL:    "https://lastfm.freetls.fastly.net/i/u/300x300/band.png",
+			ReleaseYear: 2009,
+			Confidence:  98,
+		},
+		{
+			ID:         "https://www.last.fm/music/Tim+Buckley/Greetings+from+L.A.",
+			ArtistName: "Tim Buckley",
+			Name:       "Greetings from L.A.",
+			CoverURL:   "https://lastfm.freetls.fastly.net/i/u/64s/greetings.png",
+			Confidence: 97,
+		},
+	}
+	if !reflect.DeepEqual(as, expected) {
+		t.Errorf("expected %+v got %+v", expected, as)
+	}
+}
+
+func TestLastFMSearchArtists(t *testing.T) {
+	lfmp := newLastFMProvider(t, "valid")
+	lfmp.ArtistEnable()
+
+	as, err := lfmp.SearchArtists(context.Background(), "Tim Buckley")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Last.fm serves its placeholder for most artists.
+	expected := []provider.ArtistResult{
+		{
+			ID:         "a3d8aa7b-4b3f-4f43-9c9e-1d7a0ad4f2e1",
+			Name:       "Tim Buckley",
+			Confidence: 100,
+		},
+		{
+			ID:         "https://www.last.fm/music/Tim+Buckley+&+Larry+Beckett",
+			Name:       "Tim Buckley & Larry Beckett",
+			ImageURL:   "https://lastfm.freetls.fastly.net/i/u/300x300/beckett.png",
+			Confidence: 99,
+		},
+	}
+	if !reflect.DeepEqual(as, expected) {
+		t.Errorf("expected %+v got %+v", expected, as)
+	}
+}
+
+func TestLastFMErrors(t *testing.T) {
+	lfmp := newLastFMProvider(t, "invalid")
+	lfmp.ArtistEnable()
+	if _, err := lfmp.SearchArtists(context.Background(), "Tim Buckley"); err == nil {
+		t.Errorf("expected error response to fail")
+	}
+
+	t.Setenv("LAST_FM_API_KEY", "")
+	missing := provider.NewLastFMProvider()
+	missing.AlbumEnable()
+	if _, err := missing.SearchAlbums(context.Background(), "Tim Buckley", "Starsailor"); !errors.Is(err, provider.ErrMissingAPIKey) {
+		t.Errorf("expected '%v' got '%v'", provider.ErrMissingAPIKey, err)
+	}
+}
+
+type lastFMTagsTest struct {
+	name     string
+	data     string
+	expected provider.LastFMTags
+}
+
+func (tt *lastFMTagsTest) run(t *testing.T) {
+	var tags provider.LastFMTags
+	if err := json.Unmarshal([]byte(tt.data), &tags); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(tags, tt.expected) {
+		t.Errorf("expected %+v got %+v", tt.expected, tags)
+	}
+}
+
+func TestLastFMTagsUnmarshalJSON(t *testing.T) {
+	tts := []lastFMTagsTest{
+		{name: "empty string", data: `""`},
+		{name: "no tag", data: `{}`},
+		{
+			name:     "object",
+			data:     `{"tag": {"name": "1970", "url": "https://www.last.fm/tag/1970"}}`,
+			expected: provider.LastFMTags{{Name: "1970"}},
+		},
+		{
+			name:     "array",
+			data:     `{"tag": [{"name": "folk"}, {"name": "1970"}]}`,
+			expected: provider.LastFMTags{{Name: "folk"}, {Name: "1970"}},
+		},
+	}
+
+	for _, tt := range tts {
+		t.Run(tt.name, tt.run)
+	}
+}
@@ -13,6 +13,12 @@ type (
 		ArtistDisable()
 		ArtistEnable()
 	}
+	// ActiveArtistProvider is implemented by artist providers that can rank
+	// the artists they find by whether they were active from one year to
+	// another, a year of 0 leaves that end open.
+	ActiveArtistProvider interface {
+		SearchActiveArtists(ctx context.Context, artist string, from, to int) ([]ArtistResult, error)
+	}
 	AlbumProvider interface {
 		Provider
 		SearchAlbums(ctx context.Context, artist, album string) ([]AlbumResult, error)
@@ -0,0 +1,123 @@
+package provider_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/waelbendhia/scruffy/app/updater/provider"
+	"github.com/waelbendhia/scruffy/app/updater/rate"
+)
+
+// redirectTransport sends every request to the test server, providers call
+// their APIs and pages with absolute URLs.
+type redirectTransport struct{ to *url.URL }
+
+func (rt redirectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme, r.URL.Host = rt.to.Scheme, rt.to.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// newTestClient starts a server for handler and returns a client that sends
+// every request to it. The server is closed when the test ends.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *http.Client {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &http.Client{Transport: redirectTransport{to: u}}
+}
+
+// testLimiter does not hold tests back.
+func testLimiter() *rate.Limiter { return rate.NewLimiter(100, time.Second) }
+
+type enableTest struct {
+	name   string
+	artist provider.ArtistProvider
+	album  provider.AlbumProvider
+	// artistName and albumName are searched for, the provider's test server
+	// answers them.
+	artistName string
+	albumName  string
+}
+
+func (et *enableTest) run(t *testing.T) {
+	ctx := context.Background()
+
+	if ap := et.artist; ap != nil {
+		search := func() error {
+			_, err := ap.SearchArtists(ctx, et.artistName)
+			return err
+		}
+
+		if err := search(); !errors.Is(err, provider.ErrDisabled) {
+			t.Fatalf("expected disabled provider to fail with '%v' got '%v'", provider.ErrDisabled, err)
+		}
+
+		ap.ArtistEnable()
+		if !ap.ArtistEnabled() {
+			t.Errorf("expected artist search to be enabled")
+		}
+		if err := search(); err != nil {
+			t.Errorf("expected enabled provider to search got '%v'", err)
+		}
+
+		ap.ArtistDisable()
+		if err := search(); !errors.Is(err, provider.ErrDisabled) {
+			t.Errorf("expected disabled provider to fail with '%v' got '%v'", provider.ErrDisabled, err)
+		}
+	}
+
+	if ap := et.album; ap != nil {
+		search := func() error {
+			_, err := ap.SearchAlbums(ctx, et.artistName, et.albumName)
+			return err
+		}
+
+		if err := search(); !errors.Is(err, provider.ErrDisabled) {
+			t.Fatalf("expected disabled provider to fail with '%v' got '%v'", provider.ErrDisabled, err)
+		}
+
+		ap.AlbumEnable()
+		if !ap.AlbumEnabled() {
+			t.Errorf("expected album search to be enabled")
+		}
+		if err := search(); err != nil {
+			t.Errorf("expected enabled provider to search got '%v'", err)
+		}
+
+		ap.AlbumDisable()
+		if err := search(); !errors.Is(err, provider.ErrDisabled) {
+			t.Errorf("expected disabled provider to fail with '%v' got '%v'", provider.ErrDisabled, err)
+		}
+	}
+}
+
+func TestProviderEnable(t *testing.T) {
+	bp := newBandcampProvider(t)
+	lfmp := newLastFMProvider(t, "valid")
+	dp := newDiscogsProvider(t)
+	ip, _ := newITunesProvider(t)
+	wp := newWikidataProvider(t)
+
+	tts := []enableTest{
+		{name: "bandcamp", artist: bp, album: bp, artistName: "Tashi Dorji", albumName: "Stateless"},
+		{name: "lastfm", artist: lfmp, album: lfmp, artistName: "Tim Buckley", albumName: "Starsailor"},
+		{name: "discogs", artist: dp, album: dp, artistName: "Can", albumName: "Tago Mago"},
+		{name: "itunes", artist: ip, album: ip, artistName: "Tim Buckley", albumName: "Starsailor"},
+		{name: "wikidata", artist: wp, artistName: "Don Cherry"},
+	}
+
+	for _, tt := range tts {
+		t.Run(tt.name, tt.run)
+	}
+}
@@ -6,6 +6,9 @@ type (
 		Name       string `json:"name"`
 		ImageURL   string `json:"imageURL,omitempty"`
 		Confidence int    `json:"confidence"`
+		// ExternalIDs are the IDs other services know the artist by, keyed by
+		// service.
+		ExternalIDs map[string]string `json:"externalIDs,omitempty"`
 	}
 	AlbumResult struct {
 		ID          string `json:"id"`
@@ -13,6 +16,9 @@ type (
 		Name        string `json:"name"`
 		CoverURL    string `json:"coverURL,omitempty"`
 		ReleaseYear int    `json:"releaseYear,omitempty"`
-		Confidence  int    `json:"confidence"`
+		// Label is the label the album was released on, when the provider
+		// has it.
+		Label      string `json:"label,omitempty"`
+		Confidence int    `json:"confidence"`
 	}
 )
@@ -0,0 +1,404 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/waelbendhia/scruffy/app/updater/rate"
+)
+
+var _ interface {
+	ArtistProvider
+	ActiveArtistProvider
+} = (*WikidataProvider)(nil)
+
+const (
+	wikidataBaseURL = "https://www.wikidata.org/w/api.php"
+	commonsFileURL  = "https://commons.wikimedia.org/wiki/Special:FilePath/"
+)
+
+// Wikidata properties read from artist entities.
+const (
+	wikidataInstanceOf      = "P31"
+	wikidataImage           = "P18"
+	wikidataOccupation      = "P106"
+	wikidataBirth           = "P569"
+	wikidataDeath           = "P570"
+	wikidataInception       = "P571"
+	wikidataDissolved       = "P576"
+	wikidataWorkPeriodStart = "P2031"
+	wikidataWorkPeriodEnd   = "P2032"
+	wikidataMusicBrainzID   = "P434"
+	wikidataDiscogsID       = "P1953"
+	wikidataAllMusicID      = "P1728"
+)
+
+// Keys of ArtistResult.ExternalIDs.
+const (
+	ExternalIDMusicBrainz = "musicbrainz"
+	ExternalIDDiscogs     = "discogs"
+	ExternalIDAllMusic    = "allmusic"
+)
+
+var wikidataExternalIDs = map[string]string{
+	wikidataMusicBrainzID: ExternalIDMusicBrainz,
+	wikidataDiscogsID:     ExternalIDDiscogs,
+	wikidataAllMusicID:    ExternalIDAllMusic,
+}
+
+type (
+	WikidataOption   func(*WikidataProvider)
+	WikidataProvider struct {
+		artist disableable
+		client *http.Client
+		limit  *rate.Limiter
+	}
+	// WikidataHints narrow down the entities an artist name can match.
+	// Entities must either have one of Occupations or be an instance of one of
+	// Groups. When ActiveFrom or ActiveTo are set, entities known to have been
+	// active outside of those years are ranked below the others.
+	WikidataHints struct {
+		Occupations []string
+		Groups      []string
+		ActiveFrom  int
+		ActiveTo    int
+	}
+	WikidataSearchResult struct {
+		Search []struct {
+			ID          string `json:"id"`
+			Label       string `json:"label"`
+			Description string `json:"description"`
+		} `json:"search"`
+	}
+	WikidataSnak struct {
+		DataValue struct {
+			Type  string          `json:"type"`
+			Value json.RawMessage `json:"value"`
+		} `json:"datavalue"`
+	}
+	WikidataClaim struct {
+		MainSnak WikidataSnak `json:"mainsnak"`
+		Rank     string       `json:"rank"`
+	}
+	WikidataEntity struct {
+		ID     string `json:"id"`
+		Labels map[string]struct {
+			Value string `json:"value"`
+		} `json:"labels"`
+		Claims map[string][]WikidataClaim `json:"claims"`
+	}
+	WikidataEntitiesResult struct {
+		Entities map[string]WikidataEntity `json:"entities"`
+	}
+)
+
+func (wp *WikidataProvider) ArtistDisable()      { wp.artist.disable() }
+func (wp *WikidataProvider) ArtistEnable()       { wp.artist.enable() }
+func (wp *WikidataProvider) ArtistEnabled() bool { return wp.artist.enabled() }
+
+func (*WikidataProvider) Name() string { return "wikidata" }
+
+func WikidataWithClient(client *http.Client) WikidataOption {
+	return func(wp *WikidataProvider) { wp.client = client }
+}
+
+func WikidataWithRateLimiter(l *rate.Limiter) WikidataOption {
+	return func(wp *WikidataProvider) { wp.limit = l }
+}
+
+func NewWikidataProvider(opts ...WikidataOption) *WikidataProvider {
+	wp := &WikidataProvider{}
+	for _, opt := range opts {
+		opt(wp)
+	}
+
+	if wp.client == nil {
+		wp.client = &http.Client{}
+	}
+
+	if wp.limit == nil {
+		wp.limit = rate.NewLimiter(10, time.Second)
+	}
+
+	return wp
+}
+
+// DefaultWikidataHints matches musicians and musical groups active at any
+// time.
+func DefaultWikidataHints() WikidataHints {
+	return WikidataHints{
+		Occupations: []string{
+			"Q639669",   // musician
+			"Q177220",   // singer
+			"Q36834",    // composer
+			"Q488205",   // singer-songwriter
+			"Q753110",   // songwriter
+			"Q855091",   // guitarist
+			"Q486748",   // pianist
+			"Q15981151", // jazz musician
+			"Q386854",   // drummer
+			"Q183945",   // record producer
+			"Q806349",   // bandleader
+		},
+		Groups: []string{
+			"Q215380",  // musical group
+			"Q5741069", // rock band
+			"Q2088357", // musical ensemble
+		},
+	}
+}
+
+func (e *WikidataEntity) label() string {
+	if l, ok := e.Labels["en"]; ok {
+		return l.Value
+	}
+	for _, l := range e.Labels {
+		return l.Value
+	}
+	return ""
+}
+
+// values returns the values of the claims on property, deprecated claims are
+// skipped.
+func (e *WikidataEntity) values(property string) []WikidataSnak {
+	var snaks []WikidataSnak
+	for _, c := range e.Claims[property] {
+		if c.Rank != "deprecated" && len(c.MainSnak.DataValue.Value) != 0 {
+			snaks = append(snaks, c.MainSnak)
+		}
+	}
+	return snaks
+}
+
+func (s WikidataSnak) string() string {
+	var v string
+	if s.DataValue.Type == "string" || s.DataValue.Type == "external-id" {
+		_ = json.Unmarshal(s.DataValue.Value, &v)
+	}
+	return v
+}
+
+func (s WikidataSnak) entityID() string {
+	var v struct {
+		ID string `json:"id"`
+	}
+	if s.DataValue.Type == "wikibase-entityid" {
+		_ = json.Unmarshal(s.DataValue.Value, &v)
+	}
+	return v.ID
+}
+
+// year returns the year of a time value, Wikidata times look like
+// "+1967-00-00T00:00:00Z".
+func (s WikidataSnak) year() int {
+	var v struct {
+		Time string `json:"time"`
+	}
+	if s.DataValue.Type != "time" || json.Unmarshal(s.DataValue.Value, &v) != nil {
+		return 0
+	}
+
+	y, _, _ := strings.Cut(strings.TrimPrefix(v.Time, "+"), "-")
+	year, _ := strconv.Atoi(y)
+	return year
+}
+
+func (e *WikidataEntity) firstYear(properties ...string) int {
+	for _, p := range properties {
+		for _, s := range e.values(p) {
+			if y := s.year(); y != 0 {
+				return y
+			}
+		}
+	}
+	return 0
+}
+
+func (e *WikidataEntity) hasEntity(property string, ids []string) bool {
+	for _, s := range e.values(property) {
+		if slices.Contains(ids, s.entityID()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *WikidataEntity) image() string {
+	for _, s := range e.values(wikidataImage) {
+		if f := s.string(); f != "" {
+			return commonsFileURL + url.PathEscape(strings.ReplaceAll(f, " ", "_"))
+		}
+	}
+	return ""
+}
+
+func (e *WikidataEntity) externalIDs() map[string]string {
+	ids := map[string]string{}
+	for p, key := range wikidataExternalIDs {
+		for _, s := range e.values(p) {
+			if v := s.string(); v != "" {
+				ids[key] = v
+				break
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return ids
+}
+
+// activeOutside returns whether e is known to have been active only outside
+// of the hinted years. People are assumed to be active from their twenties
+// when their work period is unknown.
+func (h WikidataHints) activeOutside(e *WikidataEntity) bool {
+	from := e.firstYear(wikidataWorkPeriodStart, wikidataInception)
+	if from == 0 {
+		if born := e.firstYear(wikidataBirth); born != 0 {
+			from = born + 20
+		}
+	}
+	to := e.firstYear(wikidataWorkPeriodEnd, wikidataDissolved, wikidataDeath)
+
+	return (h.ActiveTo != 0 && from != 0 && from > h.ActiveTo) ||
+		(h.ActiveFrom != 0 && to != 0 && to < h.ActiveFrom)
+}
+
+func (h WikidataHints) matches(e *WikidataEntity) bool {
+	return e.hasEntity(wikidataOccupation, h.Occupations) ||
+		e.hasEntity(wikidataInstanceOf, h.Groups)
+}
+
+func (wp *WikidataProvider) get(
+	ctx context.Context, params map[string]string,
+) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", wikidataBaseURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+
+	q := req.URL.Query()
+	q.Add("format", "json")
+	for k, v := range params {
+		q.Add(k, v)
+	}
+	req.URL.RawQuery = q.Encode()
+
+	if err := wp.limit.Do(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := wp.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	return resp, nil
+}
+
+// SearchArtists implements ArtistProvider.
+func (wp *WikidataProvider) SearchArtists(
+	ctx context.Context, artist string,
+) ([]ArtistResult, error) {
+	return wp.SearchArtistsWithHints(ctx, artist, DefaultWikidataHints())
+}
+
+// SearchActiveArtists implements ActiveArtistProvider, artists known to have
+// been active only outside of from and to are ranked below the others.
+func (wp *WikidataProvider) SearchActiveArtists(
+	ctx context.Context, artist string, from, to int,
+) ([]ArtistResult, error) {
+	hints := DefaultWikidataHints()
+	hints.ActiveFrom, hints.ActiveTo = from, to
+	return wp.SearchArtistsWithHints(ctx, artist, hints)
+}
+
+// SearchArtistsWithHints searches the entities labelled artist and returns
+// those that match hints along with their Commons image and the IDs other
+// providers know them by.
+func (wp *WikidataProvider) SearchArtistsWithHints(
+	ctx context.Context, artist string, hints WikidataHints,
+) ([]ArtistResult, error) {
+	if !wp.artist.enabled() {
+		return nil, ErrDisabled
+	}
+
+	resp, err := wp.get(ctx, map[string]string{
+		"action":   "wbsearchentities",
+		"search":   artist,
+		"language": "en",
+		"type":     "item",
+		"limit":    "10",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sr, err := readRespJSON[WikidataSearchResult](resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sr.Search) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(sr.Search))
+	for _, s := range sr.Search {
+		ids = append(ids, s.ID)
+	}
+
+	resp, err = wp.get(ctx, map[string]string{
+		"action":    "wbgetentities",
+		"ids":       strings.Join(ids, "|"),
+		"props":     "labels|claims",
+		"languages": "en",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	er, err := readRespJSON[WikidataEntitiesResult](resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var matching, outside []ArtistResult
+	for _, id := range ids {
+		e, ok := er.Entities[id]
+		if !ok || !hints.matches(&e) {
+			continue
+		}
+
+		res := ArtistResult{
+			ID:          e.ID,
+			Name:        e.label(),
+			ImageURL:    e.image(),
+			ExternalIDs: e.externalIDs(),
+		}
+		if hints.activeOutside(&e) {
+			outside = append(outside, res)
+		} else {
+			matching = append(matching, res)
+		}
+	}
+
+	as := append(matching, outside...)
+	for i := range as {
+		as[i].Confidence = max(0, 100-i)
+		if i >= len(matching) {
+			as[i].Confidence /= 2
+		}
+	}
+
+	return as, nil
+}
@@ -0,0 +1,96 @@
+package provider_test
+
+import (
+	"context"
+	_ "embed"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/waelbendhia/scruffy/app/updater/provider"
+)
+
+//go:embed wikidata-responses/search.json
+var wikidataSearch []byte
+
+//go:embed wikidata-responses/entities.json
+var wikidataEntities []byte
+
+func newWikidataProvider(t *testing.T) *provider.WikidataProvider {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch q.Get("action") {
+		case "wbsearchentities":
+			if q.Get("search") != "Don Cherry" {
+				t.Errorf("unexpected search '%s'", q.Get("search"))
+			}
+			w.Write(wikidataSearch)
+		case "wbgetentities":
+			if q.Get("ids") != "Q1000001|Q1000002|Q1000003|Q1000004" {
+				t.Errorf("unexpected ids '%s'", q.Get("ids"))
+			}
+			w.Write(wikidataEntities)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	return provider.NewWikidataProvider(provider.WikidataWithClient(client))
+}
+
+func TestWikidataSearchArtists(t *testing.T) {
+	wp := newWikidataProvider(t)
+	wp.ArtistEnable()
+
+	trumpeter := provider.ArtistResult{
+		ID:       "Q1000001",
+		Name:     "Don Cherry",
+		ImageURL: "https://commons.wikimedia.org/wiki/Special:FilePath/Don_Cherry_%281977%29.jpg",
+		ExternalIDs: map[string]string{
+			provider.ExternalIDMusicBrainz: "0b9dc9c3-9a9c-4e0e-a6ef-6a5e3e3a2d4f",
+			provider.ExternalIDDiscogs:     "74328",
+			provider.ExternalIDAllMusic:    "mn0000097542",
+		},
+	}
+	singer := provider.ArtistResult{
+		ID:       "Q1000002",
+		Name:     "Don Cherry",
+		ImageURL: "https://commons.wikimedia.org/wiki/Special:FilePath/Don_Cherry_golfer.jpg",
+	}
+	band := provider.ArtistResult{ID: "Q1000004", Name: "Don Cherry"}
+
+	withConfidence := func(a provider.ArtistResult, c int) provider.ArtistResult {
+		a.Confidence = c
+		return a
+	}
+
+	as, err := wp.SearchArtists(context.Background(), "Don Cherry")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The hockey commentator is not a musician.
+	expected := []provider.ArtistResult{
+		withConfidence(trumpeter, 100),
+		withConfidence(singer, 99),
+		withConfidence(band, 98),
+	}
+	if !reflect.DeepEqual(as, expected) {
+		t.Errorf("expected %+v got %+v", expected, as)
+	}
+
+	as, err = wp.SearchActiveArtists(context.Background(), "Don Cherry", 1958, 1995)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The singer stopped before 1958 and the band started after 1995.
+	expected = []provider.ArtistResult{
+		withConfidence(trumpeter, 100),
+		withConfidence(singer, 49),
+		withConfidence(band, 49),
+	}
+	if !reflect.DeepEqual(as, expected) {
+		t.Errorf("expected %+v got %+v", expected, as)
+	}
+}
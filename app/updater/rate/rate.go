@@ -23,3 +23,15 @@ func (l *Limiter) Do(ctx context.Context) error {
 		return ctx.Err()
 	}
 }
+
+// TryDo takes a slot without waiting for one, it reports whether a slot was
+// free.
+func (l *Limiter) TryDo() bool {
+	select {
+	case l.buf <- struct{}{}:
+		go func() { <-time.After(l.window); <-l.buf }()
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,82 @@
+package revision
+
+import (
+	"regexp"
+	"strings"
+)
+
+type (
+	// Op is what happened to a paragraph going from one bio to the other.
+	Op string
+	// Change is a paragraph of a diff between two bios.
+	Change struct {
+		Op   Op     `json:"op"`
+		Text string `json:"text"`
+	}
+)
+
+const (
+	OpEqual   Op = "equal"
+	OpAdded   Op = "added"
+	OpRemoved Op = "removed"
+)
+
+var paragraphSeparator = regexp.MustCompile(`\n\s*\n`)
+
+// Paragraphs splits a bio on blank lines. Whitespace inside a paragraph is
+// collapsed so that re-wrapped lines do not show up as changes.
+func Paragraphs(bio string) []string {
+	var res []string
+	for _, p := range paragraphSeparator.Split(bio, -1) {
+		if p = strings.Join(strings.Fields(p), " "); p != "" {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// Diff returns the paragraph level changes that turn from into to.
+func Diff(from, to string) []Change {
+	a, b := Paragraphs(from), Paragraphs(to)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and
+	// b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	res := make([]Change, 0, max(len(a), len(b)))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			res = append(res, Change{Op: OpEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			res = append(res, Change{Op: OpRemoved, Text: a[i]})
+			i++
+		default:
+			res = append(res, Change{Op: OpAdded, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		res = append(res, Change{Op: OpRemoved, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		res = append(res, Change{Op: OpAdded, Text: b[j]})
+	}
+
+	return res
+}
@@ -0,0 +1,53 @@
+package revision_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/waelbendhia/scruffy/app/updater/revision"
+)
+
+func TestDiff(t *testing.T) {
+	tts := []struct {
+		name     string
+		from, to string
+		expected []revision.Change
+	}{
+		{
+			name: "re-wrapped paragraph",
+			from: "first\nparagraph\n\nsecond",
+			to:   "first paragraph\n\n\n\nsecond\n",
+			expected: []revision.Change{
+				{Op: revision.OpEqual, Text: "first paragraph"},
+				{Op: revision.OpEqual, Text: "second"},
+			},
+		},
+		{
+			name: "edited and added paragraphs",
+			from: "intro\n\nold middle\n\noutro",
+			to:   "intro\n\nnew middle\n\noutro\n\ncoda",
+			expected: []revision.Change{
+				{Op: revision.OpEqual, Text: "intro"},
+				{Op: revision.OpRemoved, Text: "old middle"},
+				{Op: revision.OpAdded, Text: "new middle"},
+				{Op: revision.OpEqual, Text: "outro"},
+				{Op: revision.OpAdded, Text: "coda"},
+			},
+		},
+		{
+			name: "first revision",
+			to:   "only",
+			expected: []revision.Change{
+				{Op: revision.OpAdded, Text: "only"},
+			},
+		},
+	}
+
+	for _, tt := range tts {
+		t.Run(tt.name, func(t *testing.T) {
+			if cs := revision.Diff(tt.from, tt.to); !slices.Equal(cs, tt.expected) {
+				t.Fatalf("expected %v got %v", tt.expected, cs)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package scraper
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	bracketedSuffixRegex = regexp.MustCompile(`\s*(\([^()]*\)|\[[^\[\]]*\])\s*$`)
+	subtitleRegex        = regexp.MustCompile(`\s*(:|\s-\s|/)\s*`)
+	// versionRegex matches the suffixes and subtitles naming a different
+	// version of an album rather than a different spelling of it.
+	versionRegex       = regexp.MustCompile(`(?i)\b(live|remix(es|ed)?|demos?|acoustic|instrumentals?|unplugged)\b`)
+	apostropheReplacer = strings.NewReplacer("'", "", "’", "", "`", "")
+	// "a" and "i" are left out as they are as often words of the title, as in
+	// "I Robot".
+	leadingArticles = []string{
+		"the", "an",
+		"il", "lo", "la", "gli", "le",
+		"les", "el", "los", "las",
+		"der", "die", "das",
+	}
+)
+
+func stripDiacritics(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// NormalizeAlbumName reduces an album name to a form that is the same for
+// the different spellings Scaruffi uses for it: case, diacritics,
+// punctuation, leading articles and bracketed suffixes such as "(EP)" are
+// ignored. Suffixes naming a version of the album, such as "(Live)", are kept.
+func NormalizeAlbumName(name string) string {
+	s := strings.TrimSpace(name)
+	for {
+		loc := bracketedSuffixRegex.FindStringIndex(s)
+		if loc == nil || loc[0] == 0 || versionRegex.MatchString(s[loc[0]:]) {
+			break
+		}
+		s = s[:loc[0]]
+	}
+
+	s = stripDiacritics(strings.ToLower(s))
+	s = apostropheReplacer.Replace(strings.ReplaceAll(s, "&", " and "))
+
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(fields) > 1 && slices.Contains(leadingArticles, fields[0]) {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return strings.ToLower(strings.TrimSpace(name))
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// hasSubtitle reports whether full is short followed by a subtitle, as in
+// "Trout Mask Replica: The Deluxe Edition". Subtitles naming a version of the
+// album, as in "Trout Mask Replica: Live", are not counted.
+func hasSubtitle(full, short string) bool {
+	loc := subtitleRegex.FindStringIndex(full)
+	return loc != nil &&
+		!versionRegex.MatchString(full[loc[1]:]) &&
+		NormalizeAlbumName(full[:loc[0]]) == NormalizeAlbumName(short)
+}
+
+// AlbumNamesMatch reports whether a and b are spellings of the same album.
+func AlbumNamesMatch(a, b string) bool {
+	return NormalizeAlbumName(a) == NormalizeAlbumName(b) || hasSubtitle(a, b) || hasSubtitle(b, a)
+}
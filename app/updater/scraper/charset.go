@@ -0,0 +1,51 @@
+package scraper
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	charsetUTF8        = "utf-8"
+	charsetWindows1252 = "windows-1252"
+)
+
+// detectCharset returns the name of the charset raw is encoded in. The charset
+// is taken from the byte order mark, the Content-Type header or the meta tags,
+// in that order, and is otherwise sniffed. Many older pages declare a charset
+// that does not match their content so the declaration is only trusted when
+// the body agrees with it: declared UTF-8 that is not valid UTF-8 is read as
+// windows-1252 and valid UTF-8 that is declared as latin-1 is read as UTF-8.
+func detectCharset(raw []byte, contentType string) string {
+	_, name, _ := charset.DetermineEncoding(raw, contentType)
+	switch {
+	case name == charsetUTF8 && !utf8.Valid(raw):
+		return charsetWindows1252
+	case name == charsetWindows1252 && utf8.Valid(raw):
+		return charsetUTF8
+	default:
+		return name
+	}
+}
+
+// decodeBody transcodes raw from its charset to UTF-8.
+func decodeBody(raw []byte, contentType string) ([]byte, string, error) {
+	name := detectCharset(raw, contentType)
+	if name == charsetUTF8 {
+		return raw, name, nil
+	}
+
+	e, _ := charset.Lookup(name)
+	if e == nil {
+		return nil, name, fmt.Errorf("unsupported charset '%s'", name)
+	}
+
+	decoded, err := e.NewDecoder().Bytes(raw)
+	if err != nil {
+		return nil, name, fmt.Errorf("could not decode '%s' body: %w", name, err)
+	}
+
+	return decoded, name, nil
+}
@@ -0,0 +1,90 @@
+package scraper
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// normalizeText collapses whitespace so that re-wrapped or re-indented text
+// hashes the same.
+func normalizeText(s string) string { return strings.Join(strings.Fields(s), " ") }
+
+var paragraphBreak = regexp.MustCompile(`\n[ \t]*\n\s*`)
+
+// normalizeMarkdown collapses whitespace within paragraphs, unlike in plain
+// text paragraph breaks are part of the Markdown.
+func normalizeMarkdown(s string) string {
+	paragraphs := paragraphBreak.Split(strings.TrimSpace(s), -1)
+	for i, p := range paragraphs {
+		paragraphs[i] = normalizeText(p)
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+func writeAlbum(w io.Writer, a Album) {
+	fmt.Fprintf(
+		w, "%s\x00%s\x00%g\x00%d\x00%d\x00%s\x00%s\n",
+		a.ArtistURL, normalizeText(a.Name), a.Rating, a.Year, a.Position, a.Kind,
+		normalizeText(a.Credits),
+	)
+}
+
+// ArtistContentHash hashes what was read from an artist page: its name, bio
+// and its Markdown and language, related artists and albums. Unlike the page's
+// hash it does not change when only the page's markup does.
+func ArtistContentHash(a *Artist) string {
+	h := md5.New()
+	fmt.Fprintf(
+		h, "%s\x00%s\x00%s\x00%s\x00%s\n",
+		a.URL, normalizeText(a.Name), normalizeText(a.Bio),
+		normalizeMarkdown(a.BioMarkdown), a.BioLanguage,
+	)
+
+	related := slices.Clone(a.RelatedArtists)
+	slices.Sort(related)
+	fmt.Fprintf(h, "%s\n", strings.Join(related, "\x00"))
+
+	for _, album := range a.Albums {
+		writeAlbum(h, album)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CDReviewContentHash hashes the rows read from a ratings page, regardless of
+// the order they are listed in.
+func CDReviewContentHash(albums []Album) string {
+	rows := make([]string, 0, len(albums))
+	for _, a := range albums {
+		var b strings.Builder
+		writeAlbum(&b, a)
+		rows = append(rows, b.String())
+	}
+	slices.Sort(rows)
+
+	h := md5.New()
+	for _, r := range rows {
+		io.WriteString(h, r)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// RankingContentHash hashes a best-of list's title and entries, in order.
+func RankingContentHash(r *Ranking) string {
+	h := md5.New()
+	fmt.Fprintf(h, "%s\n", normalizeText(r.Title))
+	for _, e := range r.Entries {
+		fmt.Fprintf(
+			h, "%d\x00%s\x00%s\x00%d\n",
+			e.Rank, e.ArtistURL, normalizeText(e.AlbumName), e.Year,
+		)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,52 @@
+package scraper
+
+const (
+	NameStrategyException  = "exception"
+	NameStrategyCenterH1   = "center h1"
+	NameStrategyCenterH2   = "center h2"
+	NameStrategyCenterFont = "center font"
+	NameStrategyTitle      = "title"
+
+	BioStrategyRightColumn = "right column"
+	BioStrategyItalian     = "italian body"
+	BioStrategyNone        = "none"
+)
+
+type (
+	// Miss is a piece of a page a reader could not read and why.
+	Miss struct {
+		Text   string `json:"text"`
+		Reason string `json:"reason"`
+	}
+	// Diagnostics records which heuristics the readers used on a page and
+	// what they could not read, the readers return it with what they read.
+	Diagnostics struct {
+		NameStrategy string `json:"nameStrategy,omitempty"`
+		BioStrategy  string `json:"bioStrategy,omitempty"`
+		AlbumMisses  []Miss `json:"albumMisses,omitempty"`
+		SkippedRows  []Miss `json:"skippedRows,omitempty"`
+	}
+)
+
+// missText trims the text of a miss so that diagnostics stay small.
+func missText(s string) string {
+	s = normalizeText(s)
+	if r := []rune(s); len(r) > 120 {
+		return string(r[:120]) + "…"
+	}
+	return s
+}
+
+func (d *Diagnostics) setStrategies(name, bio string) {
+	d.NameStrategy, d.BioStrategy = name, bio
+}
+
+func (d *Diagnostics) albumMiss(text, reason string) {
+	d.AlbumMisses = append(d.AlbumMisses, Miss{Text: missText(text), Reason: reason})
+}
+
+func (d *Diagnostics) skipRow(text, reason string) {
+	d.SkippedRows = append(d.SkippedRows, Miss{Text: missText(text), Reason: reason})
+}
+
+func bioColorStrategy(color string) string { return "color " + color }
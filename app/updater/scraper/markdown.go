@@ -0,0 +1,190 @@
+package scraper
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"[", `\[`,
+	"]", `\]`,
+	"`", "\\`",
+)
+
+func collapseWhitespace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+
+	return b.String()
+}
+
+type markdownWriter struct {
+	pagePath    string
+	buf         bytes.Buffer
+	breakNeeded bool
+}
+
+func (w *markdownWriter) paragraph() {
+	if w.buf.Len() > 0 {
+		w.breakNeeded = true
+	}
+}
+
+func (w *markdownWriter) write(s string) {
+	if w.buf.Len() == 0 || w.breakNeeded {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	}
+	if s == "" {
+		return
+	}
+
+	if w.breakNeeded {
+		w.buf.Truncate(len(bytes.TrimRightFunc(w.buf.Bytes(), unicode.IsSpace)))
+		w.buf.WriteString("\n\n")
+		w.breakNeeded = false
+	} else if bytes.HasSuffix(w.buf.Bytes(), []byte(" ")) && strings.HasPrefix(s, " ") {
+		s = s[1:]
+	}
+
+	w.buf.WriteString(s)
+}
+
+func (w *markdownWriter) String() string {
+	return strings.TrimSpace(w.buf.String())
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func emphasize(s, mark string) string {
+	core := strings.TrimSpace(s)
+	if core == "" {
+		return s
+	}
+
+	start := strings.Index(s, core)
+	return s[:start] + mark + core + mark + s[start+len(core):]
+}
+
+func (w *markdownWriter) inline(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return markdownEscaper.Replace(collapseWhitespace(n.Data))
+	}
+	if n.Type != html.ElementNode {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, c := range children(n) {
+		b.WriteString(w.inline(c))
+	}
+	inner := collapseWhitespace(b.String())
+
+	switch n.Data {
+	case "br", "hr":
+		return " "
+	case "script", "style":
+		return ""
+	case "font":
+		if hasNegAttribSize(n) {
+			return ""
+		}
+		return inner
+	case "i", "em":
+		return emphasize(inner, "*")
+	case "b", "strong":
+		return emphasize(inner, "**")
+	case "a":
+		artistURL, ok := resolveArtistLink(w.pagePath, attr(n, "href"))
+		core := strings.TrimSpace(inner)
+		if !ok || core == "" {
+			return inner
+		}
+		start := strings.Index(inner, core)
+		return fmt.Sprintf(
+			"%s[%s](%s)%s",
+			inner[:start], core, artistURL, inner[start+len(core):],
+		)
+	default:
+		return inner
+	}
+}
+
+func (w *markdownWriter) block(n *html.Node) {
+	for _, c := range children(n) {
+		switch c.Type {
+		case html.TextNode:
+			w.write(w.inline(c))
+		case html.ElementNode:
+			switch c.Data {
+			case "script", "style":
+			case "font":
+				if !hasNegAttribSize(c) {
+					w.block(c)
+				}
+			case "center":
+				if slices.ContainsFunc(children(c), func(cc *html.Node) bool {
+					return cc.Data == "font" && hasNegAttribSize(cc)
+				}) {
+					continue
+				}
+				w.paragraph()
+				w.block(c)
+				w.paragraph()
+			case "br", "hr":
+				w.paragraph()
+			case "p", "div", "table", "tbody", "tr", "td", "ul", "ol", "blockquote",
+				"h1", "h2", "h3", "h4", "h5", "h6":
+				w.paragraph()
+				w.block(c)
+				w.paragraph()
+			case "li":
+				w.paragraph()
+				w.write("- ")
+				w.block(c)
+				w.paragraph()
+			default:
+				w.write(w.inline(c))
+			}
+		}
+	}
+}
+
+func getMarkdownBioFromElements(artistURL string, bioElems *goquery.Selection) string {
+	w := markdownWriter{pagePath: artistURL}
+	for _, n := range bioElems.Nodes {
+		w.block(n)
+		w.paragraph()
+	}
+
+	return w.String()
+}
@@ -0,0 +1,155 @@
+package scraper
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+type (
+	// RankingEntry is an album's place in a best-of list.
+	RankingEntry struct {
+		Rank       int
+		ArtistURL  string
+		ArtistName string
+		AlbumName  string
+		Year       int
+	}
+	// Ranking is a best-of list, its entries are in the order they are
+	// listed in.
+	Ranking struct {
+		PageURL     string
+		Title       string
+		Entries     []RankingEntry
+		Diagnostics Diagnostics
+	}
+	RankingReader func(context.Context, *goquery.Document) (*Ranking, error)
+)
+
+var (
+	// Ranks have at most three digits, so that the years of navigation
+	// tables are not read as ranks.
+	rankNumberPattern  = regexp.MustCompile(`^\s*([0-9]{1,3})[.)]?\s*$`)
+	rankingYearPattern = regexp.MustCompile(`\(([0-9]{4})\)`)
+)
+
+// readRankingEntry reads an entry out of s, the first link to an artist page
+// is the artist and the album is the entry's first italic text or, when there
+// is none, the text that follows the artist's name.
+func readRankingEntry(pagePath string, rank int, s *goquery.Selection) (RankingEntry, string) {
+	var (
+		artistURL  string
+		artistName string
+	)
+	s.Find("a[href]").EachWithBreak(func(_ int, a *goquery.Selection) bool {
+		u, ok := resolveArtistLink(pagePath, a.AttrOr("href", ""))
+		if ok {
+			artistURL, artistName = u, normalizeText(a.Text())
+		}
+		return !ok
+	})
+	if artistURL == "" || artistName == "" {
+		return RankingEntry{}, "no artist link"
+	}
+
+	text := normalizeText(s.Text())
+
+	albumName := normalizeText(s.Find("i").First().Text())
+	if albumName == "" {
+		_, rest, _ := strings.Cut(text, artistName)
+		rest = strings.TrimLeft(rest, ":,-–— ")
+		rest, _, _ = strings.Cut(rest, "(")
+		albumName = strings.TrimSpace(rest)
+	}
+	if albumName == "" {
+		return RankingEntry{}, "no album name"
+	}
+
+	year := 0
+	if m := rankingYearPattern.FindStringSubmatch(text); len(m) > 1 {
+		year, _ = strconv.Atoi(m[1])
+	}
+
+	return RankingEntry{
+		Rank:       rank,
+		ArtistURL:  artistURL,
+		ArtistName: artistName,
+		AlbumName:  albumName,
+		Year:       year,
+	}, ""
+}
+
+// rankingEntries collects the entries read from one of the layouts of a
+// best-of page, entries that are listed twice are kept once.
+type rankingEntries struct {
+	pagePath    string
+	entries     []RankingEntry
+	seen        map[RankingEntry]struct{}
+	diagnostics Diagnostics
+}
+
+func newRankingEntries(pagePath string) *rankingEntries {
+	return &rankingEntries{pagePath: pagePath, seen: map[RankingEntry]struct{}{}}
+}
+
+func (es *rankingEntries) add(rank int, s *goquery.Selection) {
+	e, reason := readRankingEntry(es.pagePath, rank, s)
+	if reason != "" {
+		es.diagnostics.skipRow(s.Text(), reason)
+		return
+	}
+
+	if _, ok := es.seen[e]; ok {
+		return
+	}
+	es.seen[e] = struct{}{}
+	es.entries = append(es.entries, e)
+}
+
+// ReadRankingPage reads best-of lists. Entries are either the items of
+// ordered lists, ranked by their position, or table rows whose first cell is
+// their rank. Pages only use one of the layouts for their list but may use
+// the other for navigation or a shorter summary, the layout that yields the
+// most entries is the list. Entries that cannot be read are recorded to the
+// diagnostics of the ranking.
+func ReadRankingPage(pagePath string) RankingReader {
+	return func(ctx context.Context, doc *goquery.Document) (*Ranking, error) {
+		lists := newRankingEntries(pagePath)
+		doc.Find("ol").Each(func(_ int, ol *goquery.Selection) {
+			rank, _ := strconv.Atoi(ol.AttrOr("start", "1"))
+			ol.ChildrenFiltered("li").Each(func(_ int, li *goquery.Selection) {
+				if v, err := strconv.Atoi(li.AttrOr("value", "")); err == nil {
+					rank = v
+				}
+				lists.add(rank, li)
+				rank++
+			})
+		})
+
+		rows := newRankingEntries(pagePath)
+		doc.Find("tr").Each(func(_ int, tr *goquery.Selection) {
+			cells := tr.ChildrenFiltered("td")
+			m := rankNumberPattern.FindStringSubmatch(cells.First().Text())
+			if len(m) <= 1 || cells.Length() < 2 {
+				return
+			}
+			rank, _ := strconv.Atoi(m[1])
+			rows.add(rank, cells.Slice(1, cells.Length()))
+		})
+
+		entries := lists
+		if len(rows.entries) > len(lists.entries) {
+			entries = rows
+		}
+
+		return &Ranking{
+			PageURL:     pagePath,
+			Title:       normalizeText(doc.Find("title").First().Text()),
+			Entries:     entries.entries,
+			Diagnostics: entries.diagnostics,
+		}, nil
+	}
+}
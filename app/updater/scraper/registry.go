@@ -0,0 +1,262 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+type (
+	// PageRule associates the pages whose path matches Pattern with a named
+	// reader. Readers that need a volume or a year read it from the first
+	// capture group of the pattern.
+	PageRule struct {
+		Pattern string `json:"pattern"`
+		Reader  string `json:"reader"`
+	}
+	RegistryConfig struct {
+		// Seeds are the pages the crawl starts from, seeds that do not match
+		// any rule are only used to discover other pages.
+		Seeds []string   `json:"seeds"`
+		Rules []PageRule `json:"rules"`
+	}
+	compiledRule struct {
+		pattern *regexp.Regexp
+		reader  string
+	}
+	Registry struct {
+		seeds []string
+		rules []compiledRule
+	}
+
+	pageReaderFactory     func(pagePath string, match []string) (PageReader, error)
+	cdReviewReaderFactory func(pagePath string, match []string) (CDReviewReader, error)
+	rankingReaderFactory  func(pagePath string, match []string) (RankingReader, error)
+)
+
+var ErrUnknownReader = errors.New("unknown page reader")
+
+func captureInt(match []string) (int, error) {
+	if len(match) < 2 {
+		return 0, errors.New("pattern has no capture group")
+	}
+	return strconv.Atoi(match[1])
+}
+
+var pageReaders = map[string]pageReaderFactory{
+	"rock": func(string, []string) (PageReader, error) {
+		return ReadArtistsFromRockPage, nil
+	},
+	"jazz": func(string, []string) (PageReader, error) {
+		return ReadArtistsFromJazzPage, nil
+	},
+	"avant": func(pagePath string, _ []string) (PageReader, error) {
+		return ReadArtistsFromAvantPage(pagePath), nil
+	},
+	"volume": func(_ string, match []string) (PageReader, error) {
+		volume, err := captureInt(match)
+		if err != nil {
+			return nil, err
+		}
+		return ReadArtistsFromVolumePage(volume), nil
+	},
+}
+
+var cdReviewReaders = map[string]cdReviewReaderFactory{
+	"cdreview-90s": func(_ string, match []string) (CDReviewReader, error) {
+		year, err := captureInt(match)
+		if err != nil {
+			return nil, err
+		}
+		return Read90sCDReviewPage(year), nil
+	},
+	"cdreview-2000s": func(_ string, match []string) (CDReviewReader, error) {
+		year, err := captureInt(match)
+		if err != nil {
+			return nil, err
+		}
+		return Read2000sCDReviewPage(year), nil
+	},
+	"cdreview-new": func(string, []string) (CDReviewReader, error) {
+		return ReadNewRatingsPage(), nil
+	},
+}
+
+var rankingReaders = map[string]rankingReaderFactory{
+	"ranking": func(pagePath string, _ []string) (RankingReader, error) {
+		return ReadRankingPage(pagePath), nil
+	},
+}
+
+func DefaultRegistryConfig() RegistryConfig {
+	seeds := []string{
+		"/music/groups.html",
+		"/jazz/musician.html",
+		"/avant/index.html",
+		"/cdreview/index.html",
+		"/cdreview/new.html",
+		"/music/best100.html",
+		"/jazz/best100.html",
+		"/ratings/index.html",
+	}
+	for v := 1; v <= 8; v++ {
+		seeds = append(seeds, fmt.Sprintf("/vol%d/", v))
+	}
+	for y := 1990; y <= time.Now().Year(); y++ {
+		seeds = append(seeds, fmt.Sprintf("/cdreview/%d.html", y))
+	}
+
+	return RegistryConfig{
+		Seeds: seeds,
+		Rules: []PageRule{
+			{Pattern: `^/music/groups\.html$`, Reader: "rock"},
+			{Pattern: `^/jazz/musician\.html$`, Reader: "jazz"},
+			{Pattern: `^/avant/index[^/]*\.html$`, Reader: "avant"},
+			{Pattern: `^/vol([0-9]+)/$`, Reader: "volume"},
+			{Pattern: `^/cdreview/new\.html$`, Reader: "cdreview-new"},
+			{Pattern: `^/cdreview/(199[0-9])\.html$`, Reader: "cdreview-90s"},
+			{Pattern: `^/cdreview/([0-9]{4})\.html$`, Reader: "cdreview-2000s"},
+			{Pattern: `^/(music|jazz)/best[0-9a-z]+\.html$`, Reader: "ranking"},
+			{Pattern: `^/ratings/[0-9][0-9a-z]*\.html$`, Reader: "ranking"},
+		},
+	}
+}
+
+func NewRegistry(cfg RegistryConfig) (*Registry, error) {
+	r := &Registry{seeds: slices.Clone(cfg.Seeds)}
+	for _, rule := range cfg.Rules {
+		_, isPageReader := pageReaders[rule.Reader]
+		_, isCDReviewReader := cdReviewReaders[rule.Reader]
+		_, isRankingReader := rankingReaders[rule.Reader]
+		if !isPageReader && !isCDReviewReader && !isRankingReader {
+			return nil, fmt.Errorf("rule '%s': %w '%s'", rule.Pattern, ErrUnknownReader, rule.Reader)
+		}
+
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("could not compile pattern '%s': %w", rule.Pattern, err)
+		}
+
+		r.rules = append(r.rules, compiledRule{pattern: pattern, reader: rule.Reader})
+	}
+
+	return r, nil
+}
+
+func DefaultRegistry() *Registry {
+	r, err := NewRegistry(DefaultRegistryConfig())
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// LoadRegistry reads a JSON encoded RegistryConfig from path.
+func LoadRegistry(path string) (*Registry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open registry config: %w", err)
+	}
+	defer f.Close()
+
+	var cfg RegistryConfig
+	if err := json.NewDecoder(f).Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("could not decode registry config: %w", err)
+	}
+
+	return NewRegistry(cfg)
+}
+
+func (r *Registry) Seeds() []string { return slices.Clone(r.seeds) }
+
+func (r *Registry) match(pagePath string) (string, []string, bool) {
+	for _, rule := range r.rules {
+		if m := rule.pattern.FindStringSubmatch(pagePath); m != nil {
+			return rule.reader, m, true
+		}
+	}
+	return "", nil, false
+}
+
+// Matches reports whether pagePath is handled by one of the rules.
+func (r *Registry) Matches(pagePath string) bool {
+	_, _, ok := r.match(pagePath)
+	return ok
+}
+
+func (r *Registry) PageReader(pagePath string) (PageReader, bool) {
+	name, m, ok := r.match(pagePath)
+	if !ok {
+		return nil, false
+	}
+
+	f, ok := pageReaders[name]
+	if !ok {
+		return nil, false
+	}
+
+	reader, err := f(pagePath, m)
+	return reader, err == nil
+}
+
+func (r *Registry) CDReviewReader(pagePath string) (CDReviewReader, bool) {
+	name, m, ok := r.match(pagePath)
+	if !ok {
+		return nil, false
+	}
+
+	f, ok := cdReviewReaders[name]
+	if !ok {
+		return nil, false
+	}
+
+	reader, err := f(pagePath, m)
+	return reader, err == nil
+}
+
+func (r *Registry) RankingReader(pagePath string) (RankingReader, bool) {
+	name, m, ok := r.match(pagePath)
+	if !ok {
+		return nil, false
+	}
+
+	f, ok := rankingReaders[name]
+	if !ok {
+		return nil, false
+	}
+
+	reader, err := f(pagePath, m)
+	return reader, err == nil
+}
+
+// DiscoverPages returns the pages linked from the page at pagePath that are
+// handled by the registry. Links to a directory's index.html are reported as
+// the directory when the registry handles it, so /vol9/index.html becomes
+// /vol9/.
+func (r *Registry) DiscoverPages(
+	ctx context.Context, pagePath string, doc *goquery.Document,
+) []string {
+	var ps []string
+	for p := range linksAsMap(ctx, pagePath, doc.Find("a[href]")) {
+		if dir, ok := strings.CutSuffix(p, "index.html"); ok && r.Matches(dir) {
+			p = dir
+		}
+
+		if p != pagePath && r.Matches(p) && !slices.Contains(ps, p) {
+			ps = append(ps, p)
+		}
+	}
+
+	slices.Sort(ps)
+
+	return ps
+}
@@ -0,0 +1,106 @@
+package scraper_test
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"slices"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/waelbendhia/scruffy/app/updater/scraper"
+)
+
+//go:embed list-pages/2000.html
+var page2000 []byte
+
+func TestRegistryDiscoverPages(t *testing.T) {
+	r := scraper.DefaultRegistry()
+
+	tts := []struct {
+		name     string
+		page     []byte
+		pagePath string
+		expected []string
+	}{
+		{
+			name:     "avant sub-indexes",
+			page:     pageAvant,
+			pagePath: "/avant/index.html",
+			expected: []string{
+				"/avant/index2.html",
+				"/avant/indexit.html",
+				"/jazz/musician.html",
+				"/music/groups.html",
+			},
+		},
+		{
+			name:     "volumes",
+			page:     pageVol1,
+			pagePath: "/vol1/",
+			expected: []string{
+				"/music/best100.html",
+				"/music/groups.html",
+				"/vol2/", "/vol3/", "/vol4/", "/vol5/", "/vol6/", "/vol7/", "/vol8/",
+			},
+		},
+		{
+			name:     "cdreview years",
+			page:     page2000,
+			pagePath: "/cdreview/2000.html",
+			expected: []string{"/cdreview/1999.html", "/cdreview/2001.html", "/ratings/2000.html"},
+		},
+	}
+
+	for _, tt := range tts {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(bytes.NewReader(tt.page))
+			if err != nil {
+				t.Fatal("could not create goquery Document")
+			}
+
+			ps := r.DiscoverPages(context.Background(), tt.pagePath, doc)
+			if !slices.Equal(ps, tt.expected) {
+				t.Fatalf("expected to discover %v got %v", tt.expected, ps)
+			}
+		})
+	}
+}
+
+func TestRegistryReaders(t *testing.T) {
+	r := scraper.DefaultRegistry()
+
+	for _, p := range []string{"/music/groups.html", "/avant/index2.html", "/vol9/"} {
+		if _, ok := r.PageReader(p); !ok {
+			t.Fatalf("expected a page reader for '%s'", p)
+		}
+	}
+
+	for _, p := range []string{"/cdreview/new.html", "/cdreview/1995.html", "/cdreview/2031.html"} {
+		if _, ok := r.CDReviewReader(p); !ok {
+			t.Fatalf("expected a cd review reader for '%s'", p)
+		}
+	}
+
+	for _, p := range []string{"/music/best100.html", "/jazz/best100.html", "/ratings/1995.html"} {
+		if _, ok := r.RankingReader(p); !ok {
+			t.Fatalf("expected a ranking reader for '%s'", p)
+		}
+	}
+
+	for _, p := range []string{"/music/best.html", "/ratings/index.html"} {
+		if _, ok := r.RankingReader(p); ok {
+			t.Fatalf("did not expect a ranking reader for '%s'", p)
+		}
+	}
+
+	if _, ok := r.PageReader("/cdreview/1995.html"); ok {
+		t.Fatal("did not expect a page reader for a cd review page")
+	}
+
+	if _, err := scraper.NewRegistry(scraper.RegistryConfig{
+		Rules: []scraper.PageRule{{Pattern: "^/vol9/$", Reader: "unknown"}},
+	}); err == nil {
+		t.Fatal("expected registry with unknown reader to fail")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"path"
 	"regexp"
 	"slices"
 	"strconv"
@@ -20,6 +21,57 @@ import (
 
 type PageReader func(context.Context, *goquery.Document) (map[string]string, error)
 
+func resolveArtistURL(pagePath, href string) (string, error) {
+	if strings.HasSuffix(pagePath, "/") {
+		return url.JoinPath(pagePath, href)
+	}
+	return url.JoinPath(pagePath, "../", href)
+}
+
+// CanonicalArtistURL returns the form artist URLs are fetched and stored
+// under: a clean path without query nor fragment. Links that only differ in
+// "../" segments or in the anchor they point to are the same artist. The case
+// of the path is kept as the site's paths are case sensitive.
+func CanonicalArtistURL(artistURL string) string {
+	p := artistURL
+	// url.JoinPath escapes the fragment, parsing unescapes it.
+	if u, err := url.Parse(artistURL); err == nil {
+		p = u.Path
+	}
+	p, _, _ = strings.Cut(p, "#")
+
+	return path.Clean("/" + p)
+}
+
+// ArtistURLKey returns the key artist URLs are deduplicated on: links that
+// only differ in case are the same artist.
+func ArtistURLKey(artistURL string) string {
+	return strings.ToLower(CanonicalArtistURL(artistURL))
+}
+
+// resolveArtistLink resolves an href found on the page at pagePath to an
+// artist URL, it returns false if the href does not point to an artist page.
+func resolveArtistLink(pagePath, href string) (string, bool) {
+	href = strings.TrimSpace(href)
+	if u, err := url.Parse(href); err != nil || u.IsAbs() || u.Host != "" {
+		return "", false
+	}
+
+	artistURL, err := resolveArtistURL(pagePath, href)
+	if err != nil {
+		return "", false
+	}
+	artistURL = CanonicalArtistURL(artistURL)
+
+	key := ArtistURLKey(artistURL)
+	_, isBlackListed := blackList[key]
+	if isBlackListed || !artistURLRegex.MatchString(key) {
+		return "", false
+	}
+
+	return artistURL, true
+}
+
 func linksAsMap(
 	ctx context.Context, pagePath string, sel *goquery.Selection,
 ) map[string]string {
@@ -30,7 +82,7 @@ func linksAsMap(
 			return
 		}
 
-		artistURL, err := url.JoinPath(pagePath, "../", href)
+		artistURL, err := resolveArtistURL(pagePath, href)
 		if err != nil {
 			logging.GetLogger(ctx).
 				With(zap.Error(err), zap.String("href", href)).
@@ -44,12 +96,59 @@ func linksAsMap(
 	return as
 }
 
+// artistLinks collects artist links keyed by their canonical URL. Links that
+// only differ in case are kept under the first spelling found.
+type artistLinks struct {
+	links map[string]string
+	keys  map[string]string
+}
+
+func newArtistLinks() *artistLinks {
+	return &artistLinks{links: map[string]string{}, keys: map[string]string{}}
+}
+
+func (l *artistLinks) add(artistURL, name string) {
+	artistURL = CanonicalArtistURL(artistURL)
+	key := ArtistURLKey(artistURL)
+	if first, ok := l.keys[key]; ok && first != artistURL {
+		return
+	}
+
+	l.keys[key] = artistURL
+	l.links[artistURL] = name
+}
+
+// artistLinksAsMap is linksAsMap with canonical artist URLs.
+func artistLinksAsMap(
+	ctx context.Context, pagePath string, sel *goquery.Selection,
+) map[string]string {
+	as := newArtistLinks()
+	sel.Each(func(i int, s *goquery.Selection) {
+		href, ok := s.Attr("href")
+		if !ok {
+			return
+		}
+
+		artistURL, err := resolveArtistURL(pagePath, href)
+		if err != nil {
+			logging.GetLogger(ctx).
+				With(zap.Error(err), zap.String("href", href)).
+				Warn("could not join paths")
+			return
+		}
+
+		as.add(artistURL, strings.TrimSpace(s.Text()))
+	})
+
+	return as.links
+}
+
 const rockPagePath = "/music/groups.html"
 
 func ReadArtistsFromRockPage(
 	ctx context.Context, doc *goquery.Document,
 ) (map[string]string, error) {
-	return linksAsMap(ctx, rockPagePath, doc.Find("table:nth-of-type(3) a")), nil
+	return artistLinksAsMap(ctx, rockPagePath, doc.Find("table:nth-of-type(3) a")), nil
 }
 
 const jazzPagePath = "/jazz/musician.html"
@@ -57,14 +156,34 @@ const jazzPagePath = "/jazz/musician.html"
 func ReadArtistsFromJazzPage(
 	ctx context.Context, doc *goquery.Document,
 ) (map[string]string, error) {
-	return linksAsMap(ctx, jazzPagePath, doc.Find("[width=\"400\"] a[HREF]")), nil
+	return artistLinksAsMap(ctx, jazzPagePath, doc.Find("[width=\"400\"] a[HREF]")), nil
+}
+
+// Sub-indexes of the avant-garde section live next to the main index, e.g.
+// /avant/index2.html.
+var avantSubIndexRegex = regexp.MustCompile(`^/avant/index[^/]*\.html$`)
+
+func ReadArtistsFromAvantPage(pagePath string) PageReader {
+	return func(
+		ctx context.Context, doc *goquery.Document,
+	) (map[string]string, error) {
+		as := artistLinksAsMap(ctx, pagePath, doc.Find("[width=\"400\"] a[HREF]"))
+		for artistURL := range as {
+			if avantSubIndexRegex.MatchString(artistURL) ||
+				validateArtistURL(artistURL) != nil {
+				delete(as, artistURL)
+			}
+		}
+
+		return as, nil
+	}
 }
 
 func ReadArtistsFromVolumePage(volume int) PageReader {
 	return func(
 		ctx context.Context, doc *goquery.Document,
 	) (map[string]string, error) {
-		as := map[string]string{}
+		as := newArtistLinks()
 		doc.Find("select>option:not(:first-child)").Each(func(i int, s *goquery.Selection) {
 			href, ok := s.Attr("value")
 			if !ok {
@@ -79,13 +198,27 @@ func ReadArtistsFromVolumePage(volume int) PageReader {
 				return
 			}
 
-			as[href] = strings.TrimSpace(s.Text())
+			as.add(href, strings.TrimSpace(s.Text()))
 		})
 
-		return as, nil
+		return as.links, nil
 	}
 }
 
+type AlbumKind string
+
+const (
+	// AlbumKindUnknown is the kind of albums that were not read from the
+	// artist's discography.
+	AlbumKindUnknown     AlbumKind = ""
+	AlbumKindStudio      AlbumKind = "studio"
+	AlbumKindLive        AlbumKind = "live"
+	AlbumKindCompilation AlbumKind = "compilation"
+	AlbumKindEP          AlbumKind = "ep"
+	AlbumKindSingle      AlbumKind = "single"
+	AlbumKindSoundtrack  AlbumKind = "soundtrack"
+)
+
 type Album struct {
 	PageURL    string
 	ArtistURL  string
@@ -93,14 +226,26 @@ type Album struct {
 	Name       string
 	Rating     float64
 	Year       int
+	// Position is the album's 1-based position in the artist's discography,
+	// 0 when the album was not read from it.
+	Position int
+	Kind     AlbumKind
+	// Credits lists the collaborators of "(with X)" annotations.
+	Credits string
+	// Aliases are the other spellings of Name the album was read under, they
+	// are set when albums read from different pages are merged.
+	Aliases []string
 }
 
 type Artist struct {
 	URL            string
 	Name           string
 	Bio            string
+	BioMarkdown    string
+	BioLanguage    string
 	RelatedArtists []string
 	Albums         []Album
+	Diagnostics    Diagnostics
 }
 
 var blackList = map[string]struct{}{
@@ -123,21 +268,19 @@ var nameExceptions = map[string]string{
 
 var artistURLRegex, _ = regexp.Compile("\\/(avant|jazz|vol).*\\.html$")
 
-func getArtistName(artistURL string, doc *goquery.Document) (name string) {
-	defer func() { name = strings.TrimSpace(name) }()
-	name, ok := nameExceptions[artistURL]
-	if ok {
-		return
+// getArtistName returns the artist's name and the strategy that found it.
+func getArtistName(artistURL string, doc *goquery.Document) (string, string) {
+	if name, ok := nameExceptions[artistURL]; ok {
+		return strings.TrimSpace(name), NameStrategyException
 	}
 
-	if name = doc.Find("center h1").Text(); name != "" {
-		return
-	}
-	if name = doc.Find("center h2").Text(); name != "" {
-		return
+	for _, strategy := range []string{NameStrategyCenterH1, NameStrategyCenterH2} {
+		if name := doc.Find(strategy).Text(); name != "" {
+			return strings.TrimSpace(name), strategy
+		}
 	}
-	name = doc.Find("center font").First().Text()
-	return
+
+	return strings.TrimSpace(doc.Find("center font").First().Text()), NameStrategyCenterFont
 }
 
 func shouldReadRightColumn(doc *goquery.Document) bool {
@@ -147,19 +290,50 @@ func shouldReadRightColumn(doc *goquery.Document) bool {
 
 }
 
-func getBioElementsByColor(doc *goquery.Document) (*goquery.Selection, bool) {
+// getBioElementsByColor returns the elements of the bio and the strategy
+// that found them.
+func getBioElementsByColor(doc *goquery.Document) (*goquery.Selection, string, bool) {
 	if shouldReadRightColumn(doc) {
-		return doc.Find(`td[bgcolor=e6dfaa]`), true
+		return doc.Find(`td[bgcolor=e6dfaa]`), BioStrategyRightColumn, true
 	}
 
 	for _, color := range []string{"eebb88", "#eebb88", "e6dfaa"} {
 		bioElems := doc.Find(fmt.Sprintf("td[bgcolor=%s]", color))
 		if len(bioElems.Nodes) > 0 {
-			return bioElems, true
+			return bioElems, bioColorStrategy(color), true
+		}
+	}
+
+	return nil, BioStrategyNone, false
+}
+
+func getRelatedArtists(artistURL string, bioElems *goquery.Selection) []string {
+	related := []string{}
+	bioElems.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		relatedURL, ok := resolveArtistLink(artistURL, s.AttrOr("href", ""))
+		if !ok || relatedURL == artistURL || slices.Contains(related, relatedURL) {
+			return
 		}
+
+		related = append(related, relatedURL)
+	})
+
+	return related
+}
+
+// ReadArtistLinks returns the artist pages linked from the bio of the artist
+// page at artistURL.
+func ReadArtistLinks(artistURL string, doc *goquery.Document) []string {
+	if isItalianPage(doc) {
+		return getRelatedArtists(artistURL, getItalianBioElements(doc))
+	}
+
+	bioElems, _, ok := getBioElementsByColor(doc)
+	if !ok {
+		return nil
 	}
 
-	return nil, false
+	return getRelatedArtists(artistURL, bioElems)
 }
 
 func children(n *html.Node) []*html.Node {
@@ -208,12 +382,7 @@ func flattenElement(n *html.Node) []*html.Node {
 	return ns
 }
 
-func getBioFromBody(doc *goquery.Document) string {
-	bioElems, ok := getBioElementsByColor(doc)
-	if !ok {
-		return ""
-	}
-
+func getBioFromElements(bioElems *goquery.Selection) string {
 	ns := []*html.Node{}
 	for _, n := range bioElems.Nodes {
 		ns = append(ns, flattenElement(n)...)
@@ -248,12 +417,53 @@ func getBioFromBody(doc *goquery.Document) string {
 var albumPattern, _ = regexp.Compile(".+,{0,1} ([0-9]*.[0-9]+|[0-9]+)\\/10")
 
 var (
-	albumNamePattern = regexp.MustCompile("(^.+)([(].*[)])|(^.+)(,)")
-	yearPattern      = regexp.MustCompile("([0-9]{4})(\\))")
-	ratingPattern    = regexp.MustCompile("(([0-9].[0-9])|[0-9])(\\/10)")
+	albumNamePattern       = regexp.MustCompile("(^.+)([(].*[)])|(^.+)(,)")
+	yearPattern            = regexp.MustCompile("([0-9]{4})(\\))")
+	ratingPattern          = regexp.MustCompile("(([0-9].[0-9])|[0-9])(\\/10)")
+	albumAnnotationPattern = regexp.MustCompile(`\(([^()]*)\)`)
+	albumSuffixPattern     = regexp.MustCompile(`^[ \t]*\(([^()\n]*)\)`)
+	annotationSplitPattern = regexp.MustCompile(`\s*(;|,|\s-\s)\s*`)
+	annotationYearPattern  = regexp.MustCompile(`^[0-9]{4}$`)
+	annotationWithPattern  = regexp.MustCompile(`(?i)(^|[,;]|\s-)\s*with\s+(.+)$`)
+	albumKindAnnotations   = map[string]AlbumKind{
+		"live":        AlbumKindLive,
+		"compilation": AlbumKindCompilation,
+		"anthology":   AlbumKindCompilation,
+		"best of":     AlbumKindCompilation,
+		"ep":          AlbumKindEP,
+		"mini-album":  AlbumKindEP,
+		"mini album":  AlbumKindEP,
+		"single":      AlbumKindSingle,
+		"soundtrack":  AlbumKindSoundtrack,
+	}
 )
 
-func getAlbums(doc *goquery.Document) []Album {
+// readAlbumAnnotations reads the kind, year and collaborators out of the
+// contents of an album's parentheses, e.g. "1974 - live" or "with John Cale".
+func readAlbumAnnotations(annotations []string) (AlbumKind, int, string) {
+	kind, year, credits := AlbumKindStudio, 0, ""
+	for _, annotation := range annotations {
+		if loc := annotationWithPattern.FindStringSubmatchIndex(annotation); loc != nil {
+			credits = strings.TrimSpace(annotation[loc[4]:loc[5]])
+			annotation = annotation[:loc[0]]
+		}
+
+		for _, token := range annotationSplitPattern.Split(annotation, -1) {
+			token = strings.ToLower(strings.TrimSpace(token))
+			if k, ok := albumKindAnnotations[token]; ok {
+				kind = k
+			} else if annotationYearPattern.MatchString(token) {
+				year, _ = strconv.Atoi(token)
+			}
+		}
+	}
+
+	return kind, year, credits
+}
+
+// getAlbums reads the discography, album entries that cannot be read are
+// recorded to d.
+func getAlbums(doc *goquery.Document, d *Diagnostics) []Album {
 	if len(doc.Find("table").Nodes) == 0 {
 		return nil
 	}
@@ -261,10 +471,20 @@ func getAlbums(doc *goquery.Document) []Album {
 	albumText := doc.Find("table").First().Find("td:nth-of-type(1)").Text()
 
 	albums := []Album{}
-	for _, a := range albumPattern.FindAllString(albumText, -1) {
+	for _, loc := range albumPattern.FindAllStringIndex(albumText, -1) {
+		a := albumText[loc[0]:loc[1]]
+
+		var annotations []string
+		for _, m := range albumAnnotationPattern.FindAllStringSubmatch(a, -1) {
+			annotations = append(annotations, m[1])
+		}
+		if m := albumSuffixPattern.FindStringSubmatch(albumText[loc[1]:]); len(m) > 1 {
+			annotations = append(annotations, m[1])
+		}
 
 		nameMatches := albumNamePattern.FindStringSubmatch(a)
 		if len(nameMatches) <= 1 {
+			d.albumMiss(a, "no album name")
 			continue
 		}
 
@@ -275,23 +495,28 @@ func getAlbums(doc *goquery.Document) []Album {
 
 		matchedRating := ratingPattern.FindStringSubmatch(a)
 		if len(matchedRating) <= 1 {
+			d.albumMiss(a, "no rating")
 			continue
 		}
 
 		rating, err := strconv.ParseFloat(string(matchedRating[1]), 64)
 		if err != nil {
+			d.albumMiss(a, "invalid rating")
 			continue
 		}
 
-		var year int
+		kind, year, credits := readAlbumAnnotations(annotations)
 		if matchedYear := yearPattern.FindStringSubmatch(a); len(matchedYear) > 1 {
 			year, _ = strconv.Atoi(string(matchedYear[1]))
 		}
 
 		albums = append(albums, Album{
-			Name:   strings.TrimSpace(name),
-			Rating: rating,
-			Year:   year,
+			Name:     strings.TrimSpace(name),
+			Rating:   rating,
+			Year:     year,
+			Position: len(albums) + 1,
+			Kind:     kind,
+			Credits:  credits,
 		})
 	}
 
@@ -306,27 +531,116 @@ var (
 
 type ArtistPageReader func(context.Context, string, *goquery.Document) (*Artist, error)
 
+const (
+	BioLanguageEnglish = "en"
+	BioLanguageItalian = "it"
+)
+
+func validateArtistURL(artistURL string) error {
+	key := strings.ToLower(artistURL)
+	_, isBlackListed := blackList[key]
+	switch {
+	case isBlackListed:
+		return ErrBlackListed
+	case !artistURLRegex.Match([]byte(key)):
+		return ErrDoesNotMatchArtistURL
+	default:
+		return nil
+	}
+}
+
+// IsArtistURL reports whether artistURL looks like an artist page that is not
+// black listed.
+func IsArtistURL(artistURL string) bool { return validateArtistURL(artistURL) == nil }
+
+func isItalianPage(doc *goquery.Document) bool {
+	// It seems pages with a white background only contain a short bio in
+	// Italian
+	return len(doc.Find("body[bgcolor=FFFFFF], body[bgcolor=ffffff]").Nodes) > 0
+}
+
 func ReadArtistFromPage(
 	ctx context.Context, artistURL string, doc *goquery.Document,
 ) (*Artist, error) {
-	_, isBlackListed := blackList[artistURL]
-	switch {
-	case isBlackListed:
-		return nil, ErrBlackListed
-	case !artistURLRegex.Match([]byte(artistURL)):
-		return nil, ErrDoesNotMatchArtistURL
+	if err := validateArtistURL(artistURL); err != nil {
+		return nil, err
 	}
 
-	if len(doc.Find("body[bgcolor=FFFFFF]").Nodes) > 0 {
-		// It seems pages with a white background only contain a short bio
-		// Italian
+	if isItalianPage(doc) {
+		return ReadItalianArtistFromPage(ctx, artistURL, doc)
+	}
+
+	var d Diagnostics
+
+	name, nameStrategy := getArtistName(artistURL, doc)
+	bioElems, bioStrategy, hasBio := getBioElementsByColor(doc)
+	d.setStrategies(nameStrategy, bioStrategy)
+
+	if name == "" {
 		return nil, fmt.Errorf(
-			"artist '%s' has non standard bio: %w",
+			"artist '%s' has no name: %w",
 			artistURL, ErrInvalidArtist,
 		)
 	}
 
-	name := getArtistName(artistURL, doc)
+	var bio, bioMarkdown string
+	var related []string
+	if hasBio {
+		bio = strings.TrimSpace(getBioFromElements(bioElems))
+		bioMarkdown = getMarkdownBioFromElements(artistURL, bioElems)
+		related = getRelatedArtists(artistURL, bioElems)
+	}
+
+	albums := getAlbums(doc, &d)
+
+	for i := range albums {
+		albums[i].PageURL = artistURL
+	}
+
+	return &Artist{
+		URL:            artistURL,
+		Name:           name,
+		Bio:            bio,
+		BioMarkdown:    bioMarkdown,
+		BioLanguage:    BioLanguageEnglish,
+		RelatedArtists: related,
+		Albums:         albums,
+		Diagnostics:    d,
+	}, nil
+}
+
+func getItalianArtistName(artistURL string, doc *goquery.Document) (string, string) {
+	if name, strategy := getArtistName(artistURL, doc); name != "" {
+		return name, strategy
+	}
+
+	title, _, _ := strings.Cut(doc.Find("title").Text(), ":")
+	return strings.TrimSpace(title), NameStrategyTitle
+}
+
+// getItalianBioElements returns the body of the page without the header,
+// discography and footer, what is left is the bio.
+func getItalianBioElements(doc *goquery.Document) *goquery.Selection {
+	body := doc.Find("body").Clone()
+	body.Find("center, table, script, style").Remove()
+	return body
+}
+
+// ReadItalianArtistFromPage reads artist pages that only have a short bio in
+// Italian. These pages have a white background and none of the colored
+// columns of the regular artist pages.
+func ReadItalianArtistFromPage(
+	ctx context.Context, artistURL string, doc *goquery.Document,
+) (*Artist, error) {
+	if err := validateArtistURL(artistURL); err != nil {
+		return nil, err
+	}
+
+	var d Diagnostics
+
+	name, nameStrategy := getItalianArtistName(artistURL, doc)
+	d.setStrategies(nameStrategy, BioStrategyItalian)
+
 	if name == "" {
 		return nil, fmt.Errorf(
 			"artist '%s' has no name: %w",
@@ -334,21 +648,41 @@ func ReadArtistFromPage(
 		)
 	}
 
-	bio := strings.TrimSpace(getBioFromBody(doc))
-	albums := getAlbums(doc)
+	bioElems := getItalianBioElements(doc)
+	albums := getAlbums(doc, &d)
 
 	for i := range albums {
 		albums[i].PageURL = artistURL
 	}
 
-	return &Artist{URL: artistURL, Name: name, Bio: bio, Albums: albums}, nil
+	return &Artist{
+		URL:            artistURL,
+		Name:           name,
+		Bio:            strings.TrimSpace(getBioFromElements(bioElems)),
+		BioMarkdown:    getMarkdownBioFromElements(artistURL, bioElems),
+		BioLanguage:    BioLanguageItalian,
+		RelatedArtists: getRelatedArtists(artistURL, bioElems),
+		Albums:         albums,
+		Diagnostics:    d,
+	}, nil
 }
 
-type CDReviewReader func(context.Context, *goquery.Document) ([]Album, error)
+type (
+	// Ratings are the albums read from a ratings page.
+	Ratings struct {
+		Albums      []Album
+		Diagnostics Diagnostics
+	}
+	CDReviewReader func(context.Context, *goquery.Document) (*Ratings, error)
+)
 
+// readRatingsFromCDReview reads the rows matching selector, rows that cannot
+// be read are recorded to the diagnostics of the result.
 func readRatingsFromCDReview(
-	doc *goquery.Document, pagePath string, year int, selector string,
-) ([]Album, error) {
+	ctx context.Context, doc *goquery.Document, pagePath string, year int, selector string,
+) (*Ratings, error) {
+	var d Diagnostics
+
 	var as []Album
 	doc.Find(selector).Each(func(i int, s *goquery.Selection) {
 		artistLink := s.Find("td > a").First()
@@ -357,6 +691,7 @@ func readRatingsFromCDReview(
 		href := artistLink.AttrOr("href", albumLink.AttrOr("href", ""))
 		artistURL, err := url.JoinPath(pagePath, "../", href)
 		if err != nil {
+			d.skipRow(s.Text(), "invalid artist link")
 			return
 		}
 
@@ -364,23 +699,36 @@ func readRatingsFromCDReview(
 		albumName := strings.TrimSpace(albumLink.Text())
 		ratingText := s.Find("td[bgcolor=f00000]").Eq(0).Text()
 
-		if href == "" || artistName == "" || albumName == "" || ratingText == "" {
+		switch {
+		case href == "":
+			d.skipRow(s.Text(), "no artist link")
+			return
+		case artistName == "":
+			d.skipRow(s.Text(), "no artist name")
+			return
+		case albumName == "":
+			d.skipRow(s.Text(), "no album name")
+			return
+		case ratingText == "":
+			d.skipRow(s.Text(), "no rating")
 			return
 		}
 
 		matchedRating := ratingPattern.FindStringSubmatch(ratingText)
 		if len(matchedRating) <= 1 {
+			d.skipRow(s.Text(), "rating does not match")
 			return
 		}
 
 		rating, err := strconv.ParseFloat(string(matchedRating[1]), 64)
 		if err != nil {
+			d.skipRow(s.Text(), "invalid rating")
 			return
 		}
 
 		as = append(as, Album{
 			PageURL:    pagePath,
-			ArtistURL:  artistURL,
+			ArtistURL:  CanonicalArtistURL(artistURL),
 			ArtistName: artistName,
 			Name:       albumName,
 			Rating:     rating,
@@ -388,12 +736,13 @@ func readRatingsFromCDReview(
 		})
 	})
 
-	return as, nil
+	return &Ratings{Albums: as, Diagnostics: d}, nil
 }
 
 func Read90sCDReviewPage(year int) CDReviewReader {
-	return func(ctx context.Context, doc *goquery.Document) ([]Album, error) {
+	return func(ctx context.Context, doc *goquery.Document) (*Ratings, error) {
 		return readRatingsFromCDReview(
+			ctx,
 			doc,
 			fmt.Sprintf("/cdreview/%d.html", year),
 			year,
@@ -403,8 +752,9 @@ func Read90sCDReviewPage(year int) CDReviewReader {
 }
 
 func Read2000sCDReviewPage(year int) CDReviewReader {
-	return func(ctx context.Context, doc *goquery.Document) ([]Album, error) {
+	return func(ctx context.Context, doc *goquery.Document) (*Ratings, error) {
 		return readRatingsFromCDReview(
+			ctx,
 			doc,
 			fmt.Sprintf("/cdreview/%d.html", year),
 			year,
@@ -414,8 +764,9 @@ func Read2000sCDReviewPage(year int) CDReviewReader {
 }
 
 func ReadNewRatingsPage() CDReviewReader {
-	return func(ctx context.Context, doc *goquery.Document) ([]Album, error) {
+	return func(ctx context.Context, doc *goquery.Document) (*Ratings, error) {
 		return readRatingsFromCDReview(
+			ctx,
 			doc,
 			"/cdreview/new.html",
 			time.Now().Year(),
@@ -7,7 +7,10 @@ import (
 	"errors"
 	"regexp"
 	"slices"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/waelbendhia/scruffy/app/updater/scraper"
@@ -19,6 +22,9 @@ var pageRock []byte
 //go:embed list-pages/jazz.html
 var pageJazz []byte
 
+//go:embed list-pages/avant.html
+var pageAvant []byte
+
 //go:embed list-pages/vol1.html
 var pageVol1 []byte
 
@@ -66,7 +72,7 @@ func TestPageReaders(t *testing.T) {
 			name:        "read rock page",
 			page:        pageRock,
 			read:        scraper.ReadArtistsFromRockPage,
-			expectedLen: 7477,
+			expectedLen: 7474,
 
 			shouldContain: map[string]string{
 				"/vol3/zztop.html":  "ZZ Top",
@@ -86,6 +92,20 @@ func TestPageReaders(t *testing.T) {
 				"/avant/zummo.html":    "Peter Zummo",
 			},
 		},
+		{
+			name:        "read avant page",
+			page:        pageAvant,
+			read:        scraper.ReadArtistsFromAvantPage("/avant/index.html"),
+			expectedLen: 50,
+
+			shouldContain: map[string]string{
+				"/avant/ashley.html":  "Robert Ashley",
+				"/avant/behrman.html": "David Behrman",
+				"/avant/bianchi.html": "Maurizio Bianchi",
+				"/jazz/bailey.html":   "Derek Bailey",
+				"/vol2/eno.html":      "Brian Eno",
+			},
+		},
 		{
 			name:        "read vol1 page",
 			page:        pageVol1,
@@ -109,7 +129,7 @@ func TestPageReaders(t *testing.T) {
 			name:        "read vol6 page",
 			page:        pageVol6,
 			read:        scraper.ReadArtistsFromVolumePage(6),
-			expectedLen: 962,
+			expectedLen: 961,
 
 			shouldContain: map[string]string{
 				"/vol6/evidence.html": "Evidence",
@@ -136,7 +156,10 @@ type artistReaderTest struct {
 	artistURL            string
 	expectErr            error
 	name                 string
+	bioLanguage          string
 	albums               []scraper.Album
+	relatedArtists       []string
+	markdownContains     []string
 	startRegex, endRegex string
 }
 
@@ -167,6 +190,11 @@ func (art *artistReaderTest) run(t *testing.T) {
 	switch {
 	case art.name != a.Name:
 		t.Fatalf("expected to find name '%s' got '%s'", art.name, a.Name)
+	case art.bioLanguage != "" && art.bioLanguage != a.BioLanguage:
+		t.Fatalf(
+			"expected bio language '%s' got '%s'",
+			art.bioLanguage, a.BioLanguage,
+		)
 	case !regexp.MustCompile(art.startRegex).Match([]byte(a.Bio)):
 		t.Fatalf(
 			"Bio '%.10s...' does not match pattern '%s'",
@@ -196,6 +224,27 @@ func (art *artistReaderTest) run(t *testing.T) {
 			t.Fatalf("expected album year '%d' go '%d'", ea.Year, ra.Year)
 		case ra.Rating != ea.Rating:
 			t.Fatalf("expected album rating '%f' go '%f'", ea.Rating, ra.Rating)
+		case ea.Position != 0 && ra.Position != ea.Position:
+			t.Fatalf("expected album position '%d' go '%d'", ea.Position, ra.Position)
+		case ea.Kind != scraper.AlbumKindUnknown && ra.Kind != ea.Kind:
+			t.Fatalf("expected album kind '%s' go '%s'", ea.Kind, ra.Kind)
+		case ra.Credits != ea.Credits:
+			t.Fatalf("expected album credits '%s' go '%s'", ea.Credits, ra.Credits)
+		}
+	}
+
+	for _, er := range art.relatedArtists {
+		if !slices.Contains(a.RelatedArtists, er) {
+			t.Fatalf(
+				"expected related artists to contain '%s' got %v",
+				er, a.RelatedArtists,
+			)
+		}
+	}
+
+	for _, em := range art.markdownContains {
+		if !strings.Contains(a.BioMarkdown, em) {
+			t.Fatalf("expected markdown bio to contain '%s'", em)
 		}
 	}
 }
@@ -203,9 +252,29 @@ func (art *artistReaderTest) run(t *testing.T) {
 //go:embed artist-pages/100gecs.html
 var page100Gecs []byte
 
+//go:embed artist-pages/ayler.html
+var pageAyler []byte
+
 //go:embed artist-pages/beatles.html
 var pageBeatles []byte
 
+//go:embed artist-pages/godspeed.html
+var pageGodspeed []byte
+
+//go:embed artist-pages/italian.html
+var pageItalian []byte
+
+//go:embed artist-pages/velvet.html
+var pageVelvet []byte
+
+// pageVelvetAnnotated is pageVelvet with the annotations Scaruffi uses for
+// live albums, compilations and collaborations.
+var pageVelvetAnnotated = func() []byte {
+	page := bytes.Replace(pageVelvet, []byte("(1974)</A>, 8/10"), []byte("(1974 - live)</A>, 8/10"), 1)
+	page = bytes.Replace(page, []byte("(1970)</A>, 6/10"), []byte("(1970, anthology)</A>, 6/10"), 1)
+	return bytes.Replace(page, []byte("(1973)</A>, 4/10"), []byte("(1973)</A>, 4/10 (with Doug Yule)"), 1)
+}()
+
 func TestArtistReader(t *testing.T) {
 	tts := []artistReaderTest{
 		{
@@ -223,8 +292,8 @@ func TestArtistReader(t *testing.T) {
 			startRegex: "^The fact that",
 			endRegex:   "they never said it\\.$",
 			albums: []scraper.Album{
-				{Name: "Please Please Me", Year: 1963, Rating: 3},
-				{Name: "With The Beatles", Year: 1963, Rating: 3},
+				{Name: "Please Please Me", Year: 1963, Rating: 3, Position: 1, Kind: scraper.AlbumKindStudio},
+				{Name: "With The Beatles", Year: 1963, Rating: 3, Position: 2},
 				{Name: "Meet The Beatles", Year: 1964, Rating: 4},
 				{Name: "Hard Days' Night", Year: 1964, Rating: 5},
 				{Name: "For Sale", Year: 1964, Rating: 3},
@@ -255,6 +324,67 @@ func TestArtistReader(t *testing.T) {
 				{Name: "Paul McCartney: Tug Of War", Year: 1982, Rating: 5},
 			},
 		},
+		{
+			page:       pageGodspeed,
+			artistURL:  "/vol6/godspeed.html",
+			name:       "Godspeed You! Black Emperor",
+			startRegex: "^Summary",
+			endRegex:   "monastic hymnody and baroque music\\.$",
+			albums: []scraper.Album{
+				{Name: "Lift Your Skinny Fists", Rating: 7.5},
+			},
+			relatedArtists: []string{
+				"/vol3/amonduul.html",
+				"/vol1/morricon.html",
+				"/vol6/adamson.html",
+				"/avant/tellier.html",
+			},
+			markdownContains: []string{
+				"**Summary**\n\nGodspeed You! Black Emperor, a large ensemble",
+				"*East Hastings* (eighteen minutes)",
+				"[Amon Duul](/vol3/amonduul.html) or [Hawkwind](/vol3/hawkwind.html).",
+				"micro-harmonic scores.\n\nThe EP **Slow Riot For New Zero Kanada**",
+			},
+		},
+		{
+			page:      pageVelvetAnnotated,
+			artistURL: "/vol1/velvet.html",
+			name:      "Velvet Underground",
+			albums: []scraper.Album{
+				{Name: "White Light White Heat", Year: 1967, Rating: 9, Position: 2, Kind: scraper.AlbumKindStudio},
+				{Name: "Live", Year: 1974, Rating: 8, Position: 4, Kind: scraper.AlbumKindLive},
+				{Name: "Loaded", Year: 1970, Rating: 6, Position: 5, Kind: scraper.AlbumKindCompilation},
+				{
+					Name:     "Squeeze",
+					Year:     1973,
+					Rating:   4,
+					Position: 6,
+					Kind:     scraper.AlbumKindStudio,
+					Credits:  "Doug Yule",
+				},
+			},
+		},
+		{
+			page:        pageItalian,
+			artistURL:   "/vol7/tamburi.html",
+			name:        "Tamburi Lontani",
+			bioLanguage: scraper.BioLanguageItalian,
+			startRegex:  "^I Tamburi Lontani sono un trio di Bologna",
+			endRegex:    "e' un disco di transizione\\.$",
+			albums: []scraper.Album{
+				{Name: "Rumori Di Fondo", Year: 1996, Rating: 6},
+				{Name: "La Notte Elettrica", Year: 1999, Rating: 7},
+				{Name: "Ultimo Inverno", Year: 2003, Rating: 5.5},
+			},
+			relatedArtists: []string{
+				"/vol6/godspeed.html",
+				"/vol3/amonduul.html",
+			},
+			markdownContains: []string{
+				"I **Tamburi Lontani** sono un trio di Bologna",
+				"[Godspeed You Black Emperor](/vol6/godspeed.html).\n\n*La Notte Elettrica*",
+			},
+		},
 	}
 
 	for _, tt := range tts {
@@ -281,11 +411,12 @@ func (crt *cdReaderTest) run(t *testing.T) {
 		t.Fatal("could not create goquery Document")
 	}
 
-	as, err := crt.read(ctx, doc)
+	res, err := crt.read(ctx, doc)
 	if err != nil {
 		t.Fatalf("cd review reader failed: %v", err)
 		return
 	}
+	as := res.Albums
 
 	if len(as) != crt.expectedLen {
 		t.Fatalf("expected to find %d artists got %d", crt.expectedLen, len(as))
@@ -347,3 +478,307 @@ func TestCDReaders(t *testing.T) {
 		t.Run(tt.name, tt.run)
 	}
 }
+
+type charsetTest struct {
+	name            string
+	page            []byte
+	contentType     string
+	expectedCharset string
+	shouldContain   string
+}
+
+func (ct *charsetTest) run(t *testing.T) {
+	page, err := scraper.NewPage(ct.page, ct.contentType, time.Time{})
+	if err != nil {
+		t.Fatalf("could not read page: %v", err)
+	}
+
+	if page.Charset != ct.expectedCharset {
+		t.Errorf("expected charset '%s' got '%s'", ct.expectedCharset, page.Charset)
+	}
+
+	text := page.Doc.Text()
+	if !utf8.ValidString(text) {
+		t.Errorf("page text is not valid UTF-8")
+	}
+	if !strings.Contains(text, ct.shouldContain) {
+		t.Errorf("expected page text to contain '%s'", ct.shouldContain)
+	}
+}
+
+func TestNewPageCharset(t *testing.T) {
+	tts := []charsetTest{
+		{
+			name:            "undeclared windows-1252",
+			page:            pageAyler,
+			expectedCharset: "windows-1252",
+			shouldContain:   "Albert Ayler’s Quintet",
+		},
+		{
+			name:            "windows-1252 declared as UTF-8",
+			page:            pageGodspeed,
+			contentType:     "text/html; charset=utf-8",
+			expectedCharset: "windows-1252",
+			shouldContain:   "il piu’ sperimen",
+		},
+		{
+			name:            "latin-1 meta tag",
+			page:            []byte("<html><head><meta charset=iso-8859-1></head><body>Bj\xf6rk</body></html>"),
+			expectedCharset: "windows-1252",
+			shouldContain:   "Björk",
+		},
+		{
+			name:            "undeclared UTF-8",
+			page:            []byte("<html><body>Caetano Veloso, Björk</body></html>"),
+			expectedCharset: "utf-8",
+			shouldContain:   "Björk",
+		},
+	}
+
+	for _, tt := range tts {
+		t.Run(tt.name, tt.run)
+	}
+}
+
+type albumNameTest struct {
+	name    string
+	a, b    string
+	matches bool
+}
+
+func (at *albumNameTest) run(t *testing.T) {
+	if got := scraper.AlbumNamesMatch(at.a, at.b); got != at.matches {
+		t.Errorf(
+			"expected match of '%s' and '%s' to be %v (normalized '%s' and '%s')",
+			at.a, at.b, at.matches,
+			scraper.NormalizeAlbumName(at.a), scraper.NormalizeAlbumName(at.b),
+		)
+	}
+}
+
+func TestAlbumNamesMatch(t *testing.T) {
+	tts := []albumNameTest{
+		{name: "case", a: "Spirit Of Eden", b: "Spirit of Eden", matches: true},
+		{name: "diacritics", a: "Música Popular", b: "Musica Popular", matches: true},
+		{name: "punctuation", a: "Don't Stand Me Down", b: "Dont Stand Me Down!", matches: true},
+		{name: "ampersand", a: "Gold & Silver", b: "Gold and Silver", matches: true},
+		{name: "leading article", a: "The Black Saint And The Sinner Lady", b: "Black Saint and the Sinner Lady", matches: true},
+		{name: "bracketed suffix", a: "Spiderland (EP) [reissue]", b: "Spiderland", matches: true},
+		{name: "subtitle", a: "Trout Mask Replica: Deluxe Edition", b: "Trout Mask Replica", matches: true},
+		{name: "live suffix", a: "Spiderland (Live)", b: "Spiderland", matches: false},
+		{name: "remix suffix", a: "Mezzanine [Remixes]", b: "Mezzanine", matches: false},
+		{name: "live subtitle", a: "Trout Mask Replica: Live in Paris", b: "Trout Mask Replica", matches: false},
+		{name: "same version", a: "Spiderland (live) (EP)", b: "Spiderland (Live)", matches: true},
+		{name: "leading I", a: "I Robot", b: "Robot", matches: false},
+		{name: "leading A", a: "A Love Supreme", b: "Love Supreme", matches: false},
+		{name: "project prefixes", a: "Hrsta: Ghosts Will Come", b: "Hrsta: Stem Stem In Electro", matches: false},
+		{name: "lone article", a: "The", b: "A", matches: false},
+		{name: "different albums", a: "Laughing Stock", b: "Spirit Of Eden", matches: false},
+	}
+
+	for _, tt := range tts {
+		t.Run(tt.name, tt.run)
+	}
+}
+
+func TestCanonicalArtistURL(t *testing.T) {
+	tts := map[string]string{
+		"/vol6/godspeed.html":             "/vol6/godspeed.html",
+		"/vol6/Godspeed.html":             "/vol6/Godspeed.html",
+		"/cdreview/../vol6/godspeed.html": "/vol6/godspeed.html",
+		"/vol4/butthole.html%23p":         "/vol4/butthole.html",
+		"/vol4/butthole.html#p":           "/vol4/butthole.html",
+		"vol5/knottmik.html?x=1":          "/vol5/knottmik.html",
+	}
+
+	for in, expected := range tts {
+		if got := scraper.CanonicalArtistURL(in); got != expected {
+			t.Errorf("expected '%s' to be canonicalised to '%s' got '%s'", in, expected, got)
+		}
+	}
+}
+
+func TestArtistURLKey(t *testing.T) {
+	tts := map[string]string{
+		"/vol6/godspeed.html":             "/vol6/godspeed.html",
+		"/vol6/Godspeed.html":             "/vol6/godspeed.html",
+		"/cdreview/../vol6/GODSPEED.html": "/vol6/godspeed.html",
+	}
+
+	for in, expected := range tts {
+		if got := scraper.ArtistURLKey(in); got != expected {
+			t.Errorf("expected the key of '%s' to be '%s' got '%s'", in, expected, got)
+		}
+	}
+}
+
+func TestArtistContentHash(t *testing.T) {
+	base := scraper.Artist{
+		URL:  "/vol1/velvet.html",
+		Name: "Velvet Underground",
+		Bio:  "The Velvet Underground are probably the most\ninfluential band.",
+		BioMarkdown: "The Velvet Underground are probably the most\ninfluential band.\n\n" +
+			"Lou Reed was the leader.",
+		BioLanguage: "en",
+		Albums: []scraper.Album{
+			{Name: "The Velvet Underground & Nico", Year: 1967, Rating: 10},
+			{Name: "White Light White Heat", Year: 1968, Rating: 9},
+		},
+	}
+	hash := scraper.ArtistContentHash(&base)
+
+	rewrapped := base
+	rewrapped.Bio = "The Velvet Underground are probably the most influential\n  band.\n"
+	rewrapped.BioMarkdown = "The Velvet Underground are probably the most influential\n  band.\n\n\n" +
+		"Lou Reed was\nthe leader.\n"
+	if scraper.ArtistContentHash(&rewrapped) != hash {
+		t.Error("expected re-wrapping the bio not to change the hash")
+	}
+
+	emphasized := base
+	emphasized.BioMarkdown = "The *Velvet Underground* are probably the most\ninfluential band.\n\n" +
+		"Lou Reed was the leader."
+	if scraper.ArtistContentHash(&emphasized) == hash {
+		t.Error("expected changing the Markdown bio to change the hash")
+	}
+
+	joined := base
+	joined.BioMarkdown = "The Velvet Underground are probably the most\ninfluential band.\n" +
+		"Lou Reed was the leader."
+	if scraper.ArtistContentHash(&joined) == hash {
+		t.Error("expected joining the bio's paragraphs to change the hash")
+	}
+
+	translated := base
+	translated.BioLanguage = "it"
+	if scraper.ArtistContentHash(&translated) == hash {
+		t.Error("expected changing the bio's language to change the hash")
+	}
+
+	rerated := base
+	rerated.Albums = slices.Clone(base.Albums)
+	rerated.Albums[1].Rating = 8.5
+	if scraper.ArtistContentHash(&rerated) == hash {
+		t.Error("expected changing a rating to change the hash")
+	}
+
+	rows := scraper.CDReviewContentHash(base.Albums)
+	if scraper.CDReviewContentHash([]scraper.Album{base.Albums[1], base.Albums[0]}) != rows {
+		t.Error("expected the order of ratings rows not to change the hash")
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	ctx := context.Background()
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(pageVelvet))
+	if err != nil {
+		t.Fatal("could not create goquery Document")
+	}
+
+	a, err := scraper.ReadArtistFromPage(ctx, "/vol1/velvet.html", doc)
+	if err != nil {
+		t.Fatalf("artist reader failed: %v", err)
+	}
+
+	d := a.Diagnostics
+
+	switch {
+	case d.NameStrategy != scraper.NameStrategyCenterH1:
+		t.Fatalf("expected name strategy '%s' got '%s'", scraper.NameStrategyCenterH1, d.NameStrategy)
+	case d.BioStrategy != "color eebb88":
+		t.Fatalf("expected bio strategy 'color eebb88' got '%s'", d.BioStrategy)
+	case len(d.AlbumMisses) != 0:
+		t.Fatalf("expected no album misses got %v", d.AlbumMisses)
+	}
+
+	doc, err = goquery.NewDocumentFromReader(bytes.NewReader(page1990))
+	if err != nil {
+		t.Fatal("could not create goquery Document")
+	}
+
+	ratings, err := scraper.Read90sCDReviewPage(1990)(ctx, doc)
+	if err != nil {
+		t.Fatalf("cd review reader failed: %v", err)
+	}
+
+	d = ratings.Diagnostics
+
+	if len(d.SkippedRows) != 20 {
+		t.Fatalf("expected 20 skipped rows got %d", len(d.SkippedRows))
+	}
+	for _, r := range d.SkippedRows {
+		if r.Reason == "" {
+			t.Fatalf("expected skipped row '%s' to have a reason", r.Text)
+		}
+	}
+}
+
+//go:embed list-pages/ratings1990.html
+var pageRatings1990 []byte
+
+func TestRankingReader(t *testing.T) {
+	ctx := context.Background()
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(pageRatings1990))
+	if err != nil {
+		t.Fatal("could not create goquery Document")
+	}
+
+	r, err := scraper.ReadRankingPage("/ratings/1990.html")(ctx, doc)
+	if err != nil {
+		t.Fatalf("ranking reader failed: %v", err)
+	}
+
+	if r.Title != "The best albums of 1990" {
+		t.Fatalf("expected title 'The best albums of 1990' got '%s'", r.Title)
+	}
+
+	expected := []scraper.RankingEntry{
+		{Rank: 1, ArtistURL: "/vol5/antbee.html", ArtistName: "Ant Bee", AlbumName: "Pure Electric Honey", Year: 1990},
+		{Rank: 2, ArtistURL: "/vol6/guycalle.html", ArtistName: "Guy Calle", AlbumName: "Automanikk", Year: 1990},
+		{Rank: 3, ArtistURL: "/vol5/slint.html", ArtistName: "Slint", AlbumName: "Tweez", Year: 1990},
+		{Rank: 5, ArtistURL: "/vol4/zoviet.html", ArtistName: "Zoviet France", AlbumName: "Look Into Me", Year: 1990},
+		{Rank: 6, ArtistURL: "/vol5/godflesh.html", ArtistName: "Godflesh", AlbumName: "Slavestate", Year: 1990},
+		{Rank: 8, ArtistURL: "/vol5/ride.html", ArtistName: "Ride", AlbumName: "Nowhere", Year: 1990},
+	}
+	if !slices.Equal(r.Entries, expected) {
+		t.Fatalf("expected entries %v got %v", expected, r.Entries)
+	}
+
+	if d := r.Diagnostics; len(d.SkippedRows) != 1 || d.SkippedRows[0].Reason != "no artist link" {
+		t.Fatalf("expected the various artists entry to be skipped got %v", d.SkippedRows)
+	}
+}
+
+//go:embed list-pages/best100.html
+var pageBest100 []byte
+
+func TestRankingReaderTableLayout(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(pageBest100))
+	if err != nil {
+		t.Fatal("could not create goquery Document")
+	}
+
+	r, err := scraper.ReadRankingPage("/music/best100.html")(context.Background(), doc)
+	if err != nil {
+		t.Fatalf("ranking reader failed: %v", err)
+	}
+
+	expected := []scraper.RankingEntry{
+		{Rank: 1, ArtistURL: "/vol1/velvet.html", ArtistName: "Velvet Underground", AlbumName: "The Velvet Underground & Nico", Year: 1967},
+		{Rank: 2, ArtistURL: "/vol2/beefheart.html", ArtistName: "Captain Beefheart", AlbumName: "Trout Mask Replica", Year: 1969},
+		{Rank: 3, ArtistURL: "/vol2/buckley.html", ArtistName: "Tim Buckley", AlbumName: "Starsailor", Year: 1970},
+		{Rank: 4, ArtistURL: "/vol1/doors.html", ArtistName: "Doors", AlbumName: "The Doors", Year: 1967},
+		{Rank: 5, ArtistURL: "/vol2/zappa.html", ArtistName: "Frank Zappa", AlbumName: "Freak Out", Year: 1966},
+		{Rank: 6, ArtistURL: "/vol3/nico.html", ArtistName: "Nico", AlbumName: "The Marble Index", Year: 1969},
+		{Rank: 8, ArtistURL: "/vol2/tangerin.html", ArtistName: "Tangerine Dream", AlbumName: "Zeit", Year: 1972},
+	}
+	if !slices.Equal(r.Entries, expected) {
+		t.Fatalf("expected entries %v got %v", expected, r.Entries)
+	}
+
+	if d := r.Diagnostics; len(d.SkippedRows) != 1 || d.SkippedRows[0].Reason != "no artist link" {
+		t.Fatalf("expected the various artists entry to be skipped got %v", d.SkippedRows)
+	}
+}
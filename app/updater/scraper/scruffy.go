@@ -1,6 +1,7 @@
 package scraper
 
 import (
+	"bytes"
 	"context"
 	"crypto/md5"
 	"encoding/hex"
@@ -13,27 +14,59 @@ import (
 )
 
 type ScruffyPage struct {
-	Doc          *goquery.Document
-	Hash         string
+	Doc  *goquery.Document
+	Hash string
+	// LastModified and ETag are the validators sent by the server, they are
+	// empty when it did not send them.
 	LastModified time.Time
+	ETag         string
+	// Raw is the body of the page as it was served, Charset is the charset
+	// it was decoded from.
+	Raw     []byte
+	Charset string
 }
 
 func ReadPage(ctx context.Context, resp *http.Response) (*ScruffyPage, error) {
-	h := md5.New()
-	body := io.TeeReader(resp.Body, h)
-	doc, err := goquery.NewDocumentFromReader(body)
+	raw, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("could not create document: %w", err)
+		return nil, fmt.Errorf("could not read body: %w", err)
+	}
+
+	lastModified, err := http.ParseTime(resp.Header.Get("last-modified"))
+	if err != nil {
+		lastModified = time.Time{}
+	}
+
+	page, err := NewPage(raw, resp.Header.Get("content-type"), lastModified)
+	if err != nil {
+		return nil, err
 	}
 
-	lastModified, err := time.Parse(time.RFC1123, resp.Header.Get("last-modified"))
+	page.ETag = resp.Header.Get("etag")
+
+	return page, nil
+}
+
+// NewPage parses a page from its raw body, it is used to read pages from
+// stored snapshots as well as fetched ones. The body is decoded to UTF-8 using
+// the charset from contentType, the page's meta tags or sniffing.
+func NewPage(raw []byte, contentType string, lastModified time.Time) (*ScruffyPage, error) {
+	h := md5.Sum(raw)
+	body, charset, err := decodeBody(raw, contentType)
 	if err != nil {
-		lastModified = time.Now()
+		return nil, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("could not create document: %w", err)
 	}
 
 	return &ScruffyPage{
-		Hash:         hex.EncodeToString(h.Sum(nil)),
+		Hash:         hex.EncodeToString(h[:]),
 		LastModified: lastModified,
 		Doc:          doc,
+		Raw:          raw,
+		Charset:      charset,
 	}, nil
 }
@@ -3,11 +3,13 @@ package server
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"sync/atomic"
 	"time"
 
@@ -15,6 +17,8 @@ import (
 	"github.com/waelbendhia/scruffy/app/updater/database"
 	"github.com/waelbendhia/scruffy/app/updater/logging"
 	"github.com/waelbendhia/scruffy/app/updater/provider"
+	"github.com/waelbendhia/scruffy/app/updater/revision"
+	"github.com/waelbendhia/scruffy/app/updater/snapshot"
 	"github.com/waelbendhia/scruffy/app/updater/status"
 	"go.uber.org/zap"
 )
@@ -23,6 +27,7 @@ type (
 	UpdateRunner interface {
 		StartUpdate(ctx context.Context) error
 		StopUpdate(ctx context.Context) error
+		Reparse(ctx context.Context, prefixes []string) error
 	}
 
 	StatusUpdater interface {
@@ -90,11 +95,24 @@ func (s *Server) Routing(r gin.IRouter) {
 
 	r.PUT("/artist/:vol/:path", s.updateArtist)
 	r.PUT("/artist/:vol/:path/album/:name", s.updateAlbum)
+	r.GET("/artist/:vol/:path/rating-history", s.getArtistRatingHistory)
+	r.GET("/artist/:vol/:path/album/:name/rating-history", s.getAlbumRatingHistory)
+	r.GET("/artist/:vol/:path/bio-revisions", s.getBioRevisions)
+	r.GET("/artist/:vol/:path/bio-revisions/diff", s.getBioRevisionDiff)
 
 	r.GET("/update/status", s.getUpdateStatus)
 	r.GET("/update/live", s.updatesSSE)
 	r.PUT("/update/stop", s.stopUpdate)
 	r.PUT("/update/start", s.startUpdate)
+	r.PUT("/update/reparse", s.reparse)
+
+	r.GET("/pages/versions", s.getPageVersions)
+	r.GET("/pages/snapshots/:hash", s.getPageSnapshot)
+	r.GET("/pages/diagnostics", s.getPageDiagnostics)
+
+	r.GET("/tombstones", s.getTombstones)
+	r.GET("/quarantine", s.getQuarantine)
+	r.DELETE("/quarantine", s.releaseQuarantine)
 
 	r.DELETE("/all-data", s.clearData)
 }
@@ -397,6 +415,204 @@ func (s *Server) stopUpdate(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+type RatingChange struct {
+	ArtistURL string    `json:"artistUrl"`
+	Album     string    `json:"album"`
+	OldRating float64   `json:"oldRating"`
+	NewRating float64   `json:"newRating"`
+	PageURL   string    `json:"pageURL"`
+	RunID     int64     `json:"runId,omitempty"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+func toRatingChanges(hs []database.RatingHistory) []RatingChange {
+	res := make([]RatingChange, 0, len(hs))
+	for _, h := range hs {
+		res = append(res, RatingChange{
+			ArtistURL: h.ArtistUrl,
+			Album:     h.AlbumName,
+			OldRating: h.OldRating,
+			NewRating: h.NewRating,
+			PageURL:   h.PageURL,
+			RunID:     h.RunId.Int64,
+			ChangedAt: h.ChangedAt,
+		})
+	}
+	return res
+}
+
+func (s *Server) getArtistRatingHistory(c *gin.Context) {
+	q := database.New(s.db)
+	artistURL := fmt.Sprintf("/%s/%s.html", c.Param("vol"), c.Param("path"))
+
+	_, err := q.GetArtist(c.Request.Context(), artistURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Artist not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal Error"})
+		c.Error(err)
+		return
+	}
+
+	hs, err := q.ListArtistRatingHistory(c.Request.Context(), artistURL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal Error"})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toRatingChanges(hs))
+}
+
+func (s *Server) getAlbumRatingHistory(c *gin.Context) {
+	q := database.New(s.db)
+	artistURL := fmt.Sprintf("/%s/%s.html", c.Param("vol"), c.Param("path"))
+
+	_, err := q.GetAlbum(c.Request.Context(), database.GetAlbumParams{
+		ArtistUrl: artistURL,
+		Name:      c.Param("name"),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Album not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal Error"})
+		c.Error(err)
+		return
+	}
+
+	hs, err := q.ListAlbumRatingHistory(c.Request.Context(), database.ListAlbumRatingHistoryParams{
+		ArtistUrl: artistURL,
+		AlbumName: c.Param("name"),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal Error"})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toRatingChanges(hs))
+}
+
+type (
+	BioRevision struct {
+		ID          int64     `json:"id"`
+		Bio         string    `json:"bio"`
+		BioMarkdown string    `json:"bioMarkdown,omitempty"`
+		RunID       int64     `json:"runId,omitempty"`
+		CreatedAt   time.Time `json:"createdAt"`
+	}
+	BioRevisionDiff struct {
+		From    int64             `json:"from"`
+		To      int64             `json:"to"`
+		Changes []revision.Change `json:"changes"`
+	}
+)
+
+func (s *Server) getBioRevisions(c *gin.Context) {
+	q := database.New(s.db)
+	artistURL := fmt.Sprintf("/%s/%s.html", c.Param("vol"), c.Param("path"))
+
+	_, err := q.GetArtist(c.Request.Context(), artistURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Artist not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal Error"})
+		c.Error(err)
+		return
+	}
+
+	rs, err := q.ListBioRevisions(c.Request.Context(), artistURL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal Error"})
+		c.Error(err)
+		return
+	}
+
+	res := make([]BioRevision, 0, len(rs))
+	for _, r := range rs {
+		res = append(res, BioRevision{
+			ID:          r.ID,
+			Bio:         r.Bio,
+			BioMarkdown: r.BioMarkdown.String,
+			RunID:       r.RunId.Int64,
+			CreatedAt:   r.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// getBioRevisionDiff diffs the revisions with IDs from and to. A missing from
+// diffs to against an empty bio.
+func (s *Server) getBioRevisionDiff(c *gin.Context) {
+	q := database.New(s.db)
+	artistURL := fmt.Sprintf("/%s/%s.html", c.Param("vol"), c.Param("path"))
+
+	var ids [2]int64
+	for i, p := range []string{"from", "to"} {
+		v := c.Query(p)
+		if v == "" && p == "from" {
+			continue
+		}
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Invalid %s revision", p)})
+			return
+		}
+		ids[i] = id
+	}
+
+	var bios [2]string
+	for i, id := range ids {
+		if id == 0 {
+			continue
+		}
+		r, err := q.GetBioRevision(c.Request.Context(), database.GetBioRevisionParams{
+			ArtistUrl: artistURL,
+			ID:        id,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Revision not found"})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal Error"})
+			c.Error(err)
+			return
+		}
+		bios[i] = r.Bio
+	}
+
+	c.JSON(http.StatusOK, BioRevisionDiff{
+		From:    ids[0],
+		To:      ids[1],
+		Changes: revision.Diff(bios[0], bios[1]),
+	})
+}
+
+type ReparseRequest struct {
+	Prefixes []string `json:"prefixes"`
+}
+
+func (s *Server) reparse(c *gin.Context) {
+	var r ReparseRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.Bind(&r); err != nil {
+			return
+		}
+	}
+
+	if err := s.updateRunner.Reparse(c.Request.Context(), r.Prefixes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal Error"})
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 func (s *Server) updatesSSE(c *gin.Context) {
 	ctx, cancel := context.WithCancel(c.Request.Context())
 	defer cancel()
@@ -413,6 +629,227 @@ func (s *Server) updatesSSE(c *gin.Context) {
 	})
 }
 
+func (s *Server) getPageVersions(c *gin.Context) {
+	pagePath := c.Query("path")
+	if pagePath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Missing page path"})
+		return
+	}
+
+	vs, err := snapshot.Versions(c.Request.Context(), database.New(s.db), pagePath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal Error"})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, vs)
+}
+
+type (
+	PageDiagnosticsSummary struct {
+		PageURL      string    `json:"pageURL"`
+		NameStrategy string    `json:"nameStrategy,omitempty"`
+		BioStrategy  string    `json:"bioStrategy,omitempty"`
+		AlbumMisses  int64     `json:"albumMisses"`
+		SkippedRows  int64     `json:"skippedRows"`
+		RecordedAt   time.Time `json:"recordedAt"`
+	}
+	PageDiagnostics struct {
+		PageURL     string          `json:"pageURL"`
+		RecordedAt  time.Time       `json:"recordedAt"`
+		Diagnostics json.RawMessage `json:"diagnostics"`
+	}
+)
+
+// getPageDiagnostics returns the diagnostics of the page at path or, without
+// a path, a summary of the pages that had misses, skipped rows or no bio.
+func (s *Server) getPageDiagnostics(c *gin.Context) {
+	q := database.New(s.db)
+
+	if pagePath := c.Query("path"); pagePath != "" {
+		d, err := q.GetPageDiagnostics(c.Request.Context(), pagePath)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Diagnostics not found"})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal Error"})
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, PageDiagnostics{
+			PageURL:     d.PageURL,
+			RecordedAt:  d.RecordedAt,
+			Diagnostics: json.RawMessage(d.Diagnostics),
+		})
+		return
+	}
+
+	ds, err := q.ListPageDiagnostics(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal Error"})
+		c.Error(err)
+		return
+	}
+
+	res := make([]PageDiagnosticsSummary, 0, len(ds))
+	for _, d := range ds {
+		res = append(res, PageDiagnosticsSummary{
+			PageURL:      d.PageURL,
+			NameStrategy: d.NameStrategy.String,
+			BioStrategy:  d.BioStrategy.String,
+			AlbumMisses:  d.AlbumMisses,
+			SkippedRows:  d.SkippedRows,
+			RecordedAt:   d.RecordedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+type (
+	ArtistTombstone struct {
+		URL       string    `json:"url"`
+		Name      string    `json:"name"`
+		DeletedAt time.Time `json:"deletedAt"`
+	}
+	AlbumTombstone struct {
+		ArtistURL string    `json:"artistUrl"`
+		Name      string    `json:"name"`
+		PageURL   string    `json:"pageURL"`
+		DeletedAt time.Time `json:"deletedAt"`
+	}
+	Tombstones struct {
+		Artists []ArtistTombstone `json:"artists"`
+		Albums  []AlbumTombstone  `json:"albums"`
+	}
+)
+
+func (s *Server) getTombstones(c *gin.Context) {
+	q := database.New(s.db)
+
+	artists, err := q.ListTombstonedArtists(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal Error"})
+		c.Error(err)
+		return
+	}
+
+	albums, err := q.ListTombstonedAlbums(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal Error"})
+		c.Error(err)
+		return
+	}
+
+	res := Tombstones{
+		Artists: make([]ArtistTombstone, 0, len(artists)),
+		Albums:  make([]AlbumTombstone, 0, len(albums)),
+	}
+	for _, a := range artists {
+		res.Artists = append(res.Artists, ArtistTombstone{
+			URL:       a.Url,
+			Name:      a.Name,
+			DeletedAt: a.DeletedAt.Time,
+		})
+	}
+	for _, a := range albums {
+		res.Albums = append(res.Albums, AlbumTombstone{
+			ArtistURL: a.ArtistUrl,
+			Name:      a.Name,
+			PageURL:   a.PageURL,
+			DeletedAt: a.DeletedAt.Time,
+		})
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+type QuarantinedPage struct {
+	PageURL       string    `json:"pageURL"`
+	Reason        string    `json:"reason"`
+	QuarantinedAt time.Time `json:"quarantinedAt"`
+}
+
+func (s *Server) getQuarantine(c *gin.Context) {
+	ps, err := database.New(s.db).ListPageQuarantine(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal Error"})
+		c.Error(err)
+		return
+	}
+
+	res := make([]QuarantinedPage, 0, len(ps))
+	for _, p := range ps {
+		res = append(res, QuarantinedPage{
+			PageURL:       p.PageURL,
+			Reason:        p.Reason,
+			QuarantinedAt: p.QuarantinedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// releaseQuarantine releases the page at path from quarantine and forgets its
+// last accepted parse, so that its next parse is accepted whatever its size.
+func (s *Server) releaseQuarantine(c *gin.Context) {
+	pagePath := c.Query("path")
+	if pagePath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Missing page path"})
+		return
+	}
+
+	tx, err := s.db.BeginTx(c.Request.Context(), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal Error"})
+		c.Error(err)
+		return
+	}
+	defer tx.Rollback()
+
+	q := database.New(tx)
+
+	n, err := q.DeletePageQuarantine(c.Request.Context(), pagePath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal Error"})
+		c.Error(err)
+		return
+	} else if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Page not quarantined"})
+		return
+	}
+
+	if err := q.DeletePageParse(c.Request.Context(), pagePath); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal Error"})
+		c.Error(err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal Error"})
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) getPageSnapshot(c *gin.Context) {
+	raw, err := snapshot.Load(c.Request.Context(), database.New(s.db), c.Param("hash"))
+	if errors.Is(err, snapshot.ErrSnapshotNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Snapshot not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Internal Error"})
+		c.Error(err)
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html", raw)
+}
+
 func (s *Server) clearData(c *gin.Context) {
 	tx, err := s.db.BeginTx(c.Request.Context(), &sql.TxOptions{
 		Isolation: sql.LevelSerializable,
@@ -431,8 +868,17 @@ func (s *Server) clearData(c *gin.Context) {
 	}()
 
 	for _, s := range []string{
+		`DELETE FROM "_RelatedArtists"`,
+		`DELETE FROM "AlbumAlias"`,
+		`DELETE FROM "RatingHistory"`,
+		`DELETE FROM "BioRevision"`,
+		`DELETE FROM "RankingEntry"`,
+		`DELETE FROM "Ranking"`,
 		`DELETE FROM "Album"`,
 		`DELETE FROM "Artist"`,
+		`DELETE FROM "PageParse"`,
+		`DELETE FROM "PageDiagnostics"`,
+		`DELETE FROM "PageQuarantine"`,
 		`DELETE FROM "UpdateHistory"`,
 	} {
 		_, err := tx.ExecContext(c.Request.Context(), s)
@@ -0,0 +1,164 @@
+package snapshot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/waelbendhia/scruffy/app/updater/database"
+)
+
+type (
+	// RetentionPolicy decides which snapshots are kept. A page's current
+	// snapshot is always kept, older ones are pruned once they are not among
+	// the page's Versions most recent ones or were last seen more than MaxAge
+	// ago. Zero values disable the corresponding limit.
+	RetentionPolicy struct {
+		Versions int
+		MaxAge   time.Duration
+	}
+	// Version is a snapshot of a page along with when it was served.
+	Version struct {
+		Hash      string    `json:"hash"`
+		FirstSeen time.Time `json:"firstSeen"`
+		LastSeen  time.Time `json:"lastSeen"`
+	}
+)
+
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+func DefaultRetentionPolicy() RetentionPolicy { return RetentionPolicy{Versions: 5} }
+
+func compress(raw []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(raw); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(content []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// Save stores raw under hash, unless it is already stored, and records that
+// the page at pagePath served it at seenOn.
+func Save(
+	ctx context.Context,
+	q *database.Queries,
+	pagePath, hash string,
+	raw []byte,
+	seenOn time.Time,
+) error {
+	content, err := compress(raw)
+	if err != nil {
+		return fmt.Errorf("could not compress snapshot: %w", err)
+	}
+
+	err = q.InsertPageSnapshot(ctx, database.InsertPageSnapshotParams{
+		Hash:      hash,
+		Content:   content,
+		CreatedAt: seenOn,
+	})
+	if err != nil {
+		return fmt.Errorf("could not insert snapshot: %w", err)
+	}
+
+	err = q.UpsertPageVersion(ctx, database.UpsertPageVersionParams{
+		PageURL: pagePath,
+		Hash:    hash,
+		SeenOn:  seenOn,
+	})
+	if err != nil {
+		return fmt.Errorf("could not upsert page version: %w", err)
+	}
+
+	return nil
+}
+
+// Load returns the page stored under hash as it was served.
+func Load(ctx context.Context, q *database.Queries, hash string) ([]byte, error) {
+	s, err := q.GetPageSnapshot(ctx, hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSnapshotNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("could not get snapshot: %w", err)
+	}
+
+	raw, err := decompress(s.Content)
+	if err != nil {
+		return nil, fmt.Errorf("could not decompress snapshot '%s': %w", hash, err)
+	}
+
+	return raw, nil
+}
+
+// Versions lists the snapshots of the page at pagePath, most recent first.
+func Versions(ctx context.Context, q *database.Queries, pagePath string) ([]Version, error) {
+	vs, err := q.ListPageVersions(ctx, pagePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not list page versions: %w", err)
+	}
+
+	res := make([]Version, 0, len(vs))
+	for _, v := range vs {
+		res = append(res, Version{Hash: v.Hash, FirstSeen: v.FirstSeen, LastSeen: v.LastSeen})
+	}
+
+	return res, nil
+}
+
+// Prune deletes the snapshots that fall outside of the retention policy and
+// returns how many were deleted.
+func Prune(ctx context.Context, db *sql.DB, p RetentionPolicy) (int64, error) {
+	keep := int64(math.MaxInt64)
+	if p.Versions > 0 {
+		keep = int64(p.Versions)
+	}
+
+	var cutoff time.Time
+	if p.MaxAge > 0 {
+		cutoff = time.Now().Add(-p.MaxAge)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := database.New(tx)
+	_, err = q.PrunePageVersions(ctx, database.PrunePageVersionsParams{
+		Cutoff: cutoff,
+		Keep:   keep,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("could not prune page versions: %w", err)
+	}
+
+	n, err := q.PrunePageSnapshots(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not prune snapshots: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return n, nil
+}
@@ -52,6 +52,7 @@ func (su *StatusUpdater) GetStatus(ctx context.Context) (*UpdateStatus, error) {
 		c := su.status
 		c.Errors = make([]string, len(c.Errors))
 		c.Errors = append(c.Errors, su.status.Errors...)
+		c.Quarantined = append([]QuarantinedPage{}, su.status.Quarantined...)
 		out <- c
 	}()
 
@@ -163,6 +164,15 @@ func (su *StatusUpdater) AddError(ctx context.Context, newErr error) error {
 	})
 }
 
+func (su *StatusUpdater) AddQuarantined(ctx context.Context, page, reason string) error {
+	return su.withUpdate(ctx, func(us UpdateStatus) UpdateStatus {
+		if us.IsUpdating {
+			us.Quarantined = append(us.Quarantined, QuarantinedPage{Page: page, Reason: reason})
+		}
+		return us
+	})
+}
+
 func (su *StatusUpdater) EndUpdate(ctx context.Context) error {
 	return su.withUpdate(ctx, func(us UpdateStatus) UpdateStatus {
 		if us.IsUpdating {
@@ -4,6 +4,11 @@ import (
 	"time"
 )
 
+type QuarantinedPage struct {
+	Page   string `json:"page"`
+	Reason string `json:"reason"`
+}
+
 type UpdateStatus struct {
 	IsUpdating bool `json:"isUpdating"`
 
@@ -15,4 +20,7 @@ type UpdateStatus struct {
 	Pages   int `json:"pages"`
 
 	Errors []string `json:"errors"`
+	// Quarantined lists the pages whose parse looked broken and was not
+	// stored.
+	Quarantined []QuarantinedPage `json:"quarantined"`
 }
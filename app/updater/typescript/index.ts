@@ -4,6 +4,11 @@ export * from "./provider";
 export type ArtistProviders = {
   spotify: boolean;
   deezer: boolean;
+  lastfm: boolean;
+  discogs: boolean;
+  wikidata: boolean;
+  itunes: boolean;
+  bandcamp: boolean;
 };
 
 export type AlbumProviders = {
@@ -11,4 +16,7 @@ export type AlbumProviders = {
   spotify: boolean;
   deezer: boolean;
   lastfm: boolean;
+  discogs: boolean;
+  itunes: boolean;
+  bandcamp: boolean;
 };
@@ -8,6 +8,11 @@ export interface ArtistResult {
   name: string;
   imageURL?: string;
   confidence: number /* int */;
+  /**
+   * ExternalIDs are the IDs other services know the artist by, keyed by
+   * service.
+   */
+  externalIDs?: { [key: string]: string};
 }
 export interface AlbumResult {
   id: string;
@@ -15,5 +20,10 @@ export interface AlbumResult {
   name: string;
   coverURL?: string;
   releaseYear?: number /* int */;
+  /**
+   * Label is the label the album was released on, when the provider
+   * has it.
+   */
+  label?: string;
   confidence: number /* int */;
 }
@@ -3,6 +3,10 @@
 //////////
 // source: types.go
 
+export interface QuarantinedPage {
+  page: string;
+  reason: string;
+}
 export interface UpdateStatus {
   isUpdating: boolean;
   updateStart?: string;
@@ -11,4 +15,9 @@ export interface UpdateStatus {
   albums: number /* int */;
   pages: number /* int */;
   errors: string[];
+  /**
+   * Quarantined lists the pages whose parse looked broken and was not
+   * stored.
+   */
+  quarantined: QuarantinedPage[];
 }
@@ -3,7 +3,10 @@ package updater
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/waelbendhia/scruffy/app/updater/database"
@@ -61,7 +64,7 @@ func (u *Updater) getAlbumImageAndYear(ctx context.Context, artist, album string
 			ctx := logging.AddField(ctx, zap.String("provider", name))
 			as, err := provider.provider.SearchAlbums(ctx, artist, album)
 			if err != nil {
-				u.error(ctx, err, "could not search albums")
+				u.providerError(ctx, err, "could not search albums")
 				return nil
 			}
 
@@ -95,6 +98,23 @@ func (u *Updater) getAlbumImageAndYear(ctx context.Context, artist, album string
 	return bestCover, bestYear
 }
 
+func (u *Updater) storedAlbumCoverAndYear(ctx context.Context, a scraper.Album) (string, int) {
+	q := database.New(u.db)
+
+	name, err := resolveAlbumName(ctx, q, a.ArtistURL, a.Name)
+	if err != nil {
+		u.error(ctx, err, "could not resolve album name")
+		name = a.Name
+	}
+
+	stored, err := q.GetAlbum(ctx, database.GetAlbumParams{ArtistUrl: a.ArtistURL, Name: name})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		u.error(ctx, err, "could not get album")
+	}
+
+	return stored.ImageUrl.String, int(stored.Year.Int64)
+}
+
 func (u *Updater) addAlbumCover(ctx context.Context, in <-chan scraper.Album) <-chan AlbumWithImage {
 	g, ctx := errgroup.WithContext(ctx)
 	out := make(chan AlbumWithImage, u.concurrency)
@@ -102,7 +122,15 @@ func (u *Updater) addAlbumCover(ctx context.Context, in <-chan scraper.Album) <-
 	for i := 0; i < u.concurrency; i++ {
 		g.Go(func() error {
 			for a := range in {
-				cover, year := u.getAlbumImageAndYear(ctx, a.ArtistName, a.Name)
+				cover, year := "", 0
+				if u.contents.isUnchanged(a.PageURL) {
+					// Nothing changed on the album's page, the cover and year
+					// it already has are kept.
+					cover, year = u.storedAlbumCoverAndYear(ctx, a)
+				}
+				if cover == "" {
+					cover, year = u.getAlbumImageAndYear(ctx, a.ArtistName, a.Name)
+				}
 				a.Year = selectNonEmpty(a.Year, year)
 				logging.GetLogger(ctx).
 					With(zap.Any("album", AlbumWithImage{
@@ -126,28 +154,186 @@ func (u *Updater) addAlbumCover(ctx context.Context, in <-chan scraper.Album) <-
 	return out
 }
 
+// albumKeys gives the different spellings of an artist's album the same
+// deduplication key.
+type albumKeys struct {
+	lock  sync.Mutex
+	names map[string][]string
+}
+
+func newAlbumKeys() *albumKeys { return &albumKeys{names: map[string][]string{}} }
+
+func (k *albumKeys) key(a scraper.Album) string {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	artistKey := scraper.ArtistURLKey(a.ArtistURL)
+	for _, n := range k.names[artistKey] {
+		if scraper.AlbumNamesMatch(n, a.Name) {
+			return fmt.Sprintf("%s - %s", artistKey, scraper.NormalizeAlbumName(n))
+		}
+	}
+
+	k.names[artistKey] = append(k.names[artistKey], a.Name)
+	return fmt.Sprintf("%s - %s", artistKey, scraper.NormalizeAlbumName(a.Name))
+}
+
+// mergeAlbums merges two spellings of the same album, preferring the one read
+// from the artist's page. The other one's spellings are kept as aliases.
+func mergeAlbums(a, b scraper.Album) scraper.Album {
+	kept, other := b, a
+	if a.ArtistURL == a.PageURL {
+		kept, other = a, b
+	}
+
+	kept.Aliases = slices.Clone(kept.Aliases)
+	for _, n := range append([]string{other.Name}, other.Aliases...) {
+		if n != kept.Name && !slices.Contains(kept.Aliases, n) {
+			kept.Aliases = append(kept.Aliases, n)
+		}
+	}
+
+	return kept
+}
+
+// deduplicateAlbums merges the albums read from artist and ratings pages,
+// preferring the ones read from the artist's page.
+func (u *Updater) deduplicateAlbums(
+	ctx context.Context, ins ...<-chan scraper.Album,
+) <-chan scraper.Album {
+	return deduplicateWith[scraper.Album](ctx, u.concurrency, newAlbumKeys().key, mergeAlbums, ins...)
+}
+
 func (u *Updater) ProcessAlbums(
 	ctx context.Context, filterUnchanged bool, ins ...<-chan scraper.Album,
 ) <-chan AlbumWithImage {
-	deduplicated := deduplicateWith[scraper.Album](
-		ctx,
-		u.concurrency,
-		func(a scraper.Album) string {
-			return fmt.Sprintf("%s - %s", a.ArtistURL, a.Name)
+	return u.addAlbumCover(ctx, u.deduplicateAlbums(ctx, ins...))
+}
+
+// resolveAlbumName returns the name of the row the album should be stored in:
+// the one a known alias points to, the one of the artist's albums whose name
+// matches or, when there is none, the album's own name.
+func resolveAlbumName(
+	ctx context.Context, q *database.Queries, artistURL, name string,
+) (string, error) {
+	stored, err := q.GetAlbumAliasName(ctx, database.GetAlbumAliasNameParams{
+		ArtistUrl:  artistURL,
+		Normalized: scraper.NormalizeAlbumName(name),
+	})
+	if err == nil {
+		return stored, nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("could not get album alias: %w", err)
+	}
+
+	names, err := q.ListArtistAlbumNames(ctx, artistURL)
+	if err != nil {
+		return "", fmt.Errorf("could not list albums: %w", err)
+	}
+
+	for _, n := range names {
+		if scraper.AlbumNamesMatch(n, name) {
+			return n, nil
+		}
+	}
+
+	return name, nil
+}
+
+func insertAlbumAliases(
+	ctx context.Context, q *database.Queries, artistURL, name string, aliases ...string,
+) error {
+	for _, alias := range aliases {
+		err := q.UpsertAlbumAlias(ctx, database.UpsertAlbumAliasParams{
+			ArtistUrl:  artistURL,
+			Alias:      alias,
+			Normalized: scraper.NormalizeAlbumName(alias),
+			Name:       name,
+		})
+		if err != nil {
+			return fmt.Errorf("could not upsert album alias '%s': %w", alias, err)
+		}
+	}
+
+	return nil
+}
+
+// insertAlbum upserts the album under name and records its rating change in
+// the same transaction, so that no change is recorded for a rating that was
+// not stored.
+func (u *Updater) insertAlbum(
+	ctx context.Context, runID int64, a AlbumWithImage, name string,
+) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := database.New(tx)
+
+	changed, err := q.InsertRatingChange(ctx, database.InsertRatingChangeParams{
+		NewRating: a.Rating,
+		PageURL:   a.PageURL,
+		RunId:     sql.NullInt64{Valid: runID != 0, Int64: runID},
+		ChangedAt: time.Now(),
+		ArtistUrl: a.ArtistURL,
+		Name:      name,
+	})
+	if err != nil {
+		return fmt.Errorf("could not record rating change: %w", err)
+	}
+
+	if err := q.UpsertAlbum(ctx, database.UpsertAlbumParams{
+		Name: name,
+		Year: sql.NullInt64{
+			Valid: a.Year != 0,
+			Int64: int64(a.Year),
 		},
-		func(a, b scraper.Album) scraper.Album {
-			if a.ArtistURL == a.PageURL {
-				return a
-			}
-			return b
+		Rating:    a.Rating,
+		ArtistUrl: a.ArtistURL,
+		ImageUrl: sql.NullString{
+			Valid:  a.CoverURL != "",
+			String: a.CoverURL,
+		},
+		PageURL: a.PageURL,
+		// Albums read from ratings pages have no position and keep
+		// the position, kind and credits they already have.
+		Position: sql.NullInt64{
+			Valid: a.Position != 0,
+			Int64: int64(a.Position),
 		},
-		ins...,
-	)
-	return u.addAlbumCover(ctx, deduplicated)
+		Kind: sql.NullString{
+			Valid:  a.Kind != scraper.AlbumKindUnknown,
+			String: string(a.Kind),
+		},
+		Credits: sql.NullString{
+			Valid:  a.Credits != "",
+			String: a.Credits,
+		},
+		SeenAt: seenNow(),
+	}); err != nil {
+		return fmt.Errorf("could not upsert album: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	if changed > 0 {
+		logging.GetLogger(ctx).With(zap.Float64("rating", a.Rating)).
+			Info("album rating changed")
+	}
+
+	return nil
 }
 
+// InsertAlbums stores the albums. Albums whose name is a different spelling of
+// one already stored for the artist are stored in that album's row and their
+// spelling is kept as an alias, as are the spellings they were merged with. Rating changes are recorded against the run
+// with ID runID, a runID of 0 records them outside of any run.
 func (u *Updater) InsertAlbums(
-	ctx context.Context, in <-chan AlbumWithImage,
+	ctx context.Context, runID int64, in <-chan AlbumWithImage,
 ) <-chan AlbumWithImage {
 	out := make(chan AlbumWithImage, u.concurrency)
 	q := database.New(u.db)
@@ -159,23 +345,39 @@ func (u *Updater) InsertAlbums(
 				zap.String("artist-url", a.ArtistURL),
 				zap.String("album", a.Name),
 			)
-			if err := q.UpsertAlbum(ctx, database.UpsertAlbumParams{
-				Name: a.Name,
-				Year: sql.NullInt64{
-					Valid: a.Year != 0,
-					Int64: int64(a.Year),
-				},
-				Rating:    a.Rating,
-				ArtistUrl: a.ArtistURL,
-				ImageUrl: sql.NullString{
-					Valid:  a.CoverURL != "",
-					String: a.CoverURL,
-				},
-				PageURL: a.PageURL,
-			}); err != nil {
-				u.error(ctx, err, "could not upsert album")
+
+			artistURL, err := resolveArtistAlias(ctx, q, a.ArtistURL)
+			if err != nil {
+				u.error(ctx, err, "could not resolve artist alias")
+				u.contents.fail(a.PageURL)
+				continue
+			}
+			a.ArtistURL = artistURL
+
+			name, err := resolveAlbumName(ctx, q, a.ArtistURL, a.Name)
+			if err != nil {
+				u.error(ctx, err, "could not resolve album name")
+				u.contents.fail(a.PageURL)
 				continue
 			}
+
+			if err := u.insertAlbum(ctx, runID, a, name); err != nil {
+				u.error(ctx, err, "could not insert album")
+				u.contents.fail(a.PageURL)
+				continue
+			}
+
+			aliases := []string{name}
+			for _, n := range append([]string{a.Name}, a.Aliases...) {
+				if !slices.Contains(aliases, n) {
+					aliases = append(aliases, n)
+				}
+			}
+			if err := insertAlbumAliases(ctx, q, a.ArtistURL, name, aliases...); err != nil {
+				u.error(ctx, err, "could not insert album aliases")
+			}
+
+			a.Name = name
 			select {
 			case out <- a:
 			case <-ctx.Done():
@@ -0,0 +1,102 @@
+package updater
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/waelbendhia/scruffy/app/updater/database"
+	"github.com/waelbendhia/scruffy/app/updater/scraper"
+)
+
+type albumMergeTest struct {
+	name   string
+	albums []scraper.Album
+}
+
+// run merges the albums the way ProcessAlbums does, stores them and checks that
+// the album from the artist's page is kept with every spelling as an alias.
+func (mt *albumMergeTest) run(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	u := NewUpdater(db)
+
+	ins := make([]<-chan scraper.Album, 0, len(mt.albums))
+	for _, a := range mt.albums {
+		in := make(chan scraper.Album, 1)
+		in <- a
+		close(in)
+		ins = append(ins, in)
+	}
+
+	merged := u.deduplicateAlbums(ctx, ins...)
+	withImages := make(chan AlbumWithImage)
+	go func() {
+		defer close(withImages)
+		for a := range merged {
+			withImages <- AlbumWithImage{Album: a}
+		}
+	}()
+
+	var stored []AlbumWithImage
+	for a := range u.InsertAlbums(ctx, 0, withImages) {
+		stored = append(stored, a)
+	}
+
+	if len(stored) != 1 {
+		t.Fatalf("expected a single album got %+v", stored)
+	}
+	if a := stored[0]; a.Name != "Trout Mask Replica" || a.PageURL != "/vol2/beefheart.html" {
+		t.Errorf("expected the album from the artist's page got %+v", a)
+	}
+
+	q := database.New(db)
+	aliases, err := q.ListArtistAlbumAliases(ctx, "/vol2/beefheart.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(aliases)
+
+	expected := []string{"Trout Mask Replica", "Trout Mask Replica: The Deluxe Edition"}
+	if !reflect.DeepEqual(aliases, expected) {
+		t.Errorf("expected aliases %v got %v", expected, aliases)
+	}
+
+	name, err := resolveAlbumName(ctx, q, "/vol2/beefheart.html", "Trout Mask Replica: The Deluxe Edition")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "Trout Mask Replica" {
+		t.Errorf("expected the review's spelling to resolve to the album got '%s'", name)
+	}
+}
+
+func TestInsertMergedAlbumAliases(t *testing.T) {
+	fromArtist := scraper.Album{
+		PageURL:    "/vol2/beefheart.html",
+		ArtistURL:  "/vol2/beefheart.html",
+		ArtistName: "Captain Beefheart",
+		Name:       "Trout Mask Replica",
+		Rating:     10,
+		Year:       1969,
+		Position:   3,
+	}
+	fromReview := scraper.Album{
+		PageURL:    "/cdreview/1969.html",
+		ArtistURL:  "/vol2/beefheart.html",
+		ArtistName: "Captain Beefheart",
+		Name:       "Trout Mask Replica: The Deluxe Edition",
+		Rating:     10,
+		Year:       1969,
+	}
+
+	tts := []albumMergeTest{
+		{name: "artist page first", albums: []scraper.Album{fromArtist, fromReview}},
+		{name: "review first", albums: []scraper.Album{fromReview, fromArtist}},
+	}
+
+	for _, tt := range tts {
+		t.Run(tt.name, tt.run)
+	}
+}
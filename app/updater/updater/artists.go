@@ -3,6 +3,8 @@ package updater
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -17,6 +19,9 @@ import (
 
 type ArtistWithImage struct {
 	ImageURL string
+	// ExternalIDs are the IDs other services know the artist by, keyed by
+	// service.
+	ExternalIDs map[string]string
 	scraper.Artist
 }
 
@@ -29,9 +34,17 @@ func (u *Updater) addArtistImage(
 	for i := 0; i < u.concurrency; i++ {
 		g.Go(func() error {
 			for a := range in {
-				cover := u.getArtistImage(ctx, a.Name)
+				res := ArtistWithImage{Artist: a}
+				if u.contents.isUnchanged(a.URL) {
+					// Nothing changed on the artist's page, the image it
+					// already has is kept.
+					res.ImageURL = u.storedArtistImage(ctx, a.URL)
+				}
+				if res.ImageURL == "" {
+					res.ImageURL, res.ExternalIDs = u.getArtistImage(ctx, a)
+				}
 				select {
-				case out <- ArtistWithImage{Artist: a, ImageURL: cover}:
+				case out <- res:
 				case <-ctx.Done():
 					return nil
 				}
@@ -46,30 +59,70 @@ func (u *Updater) addArtistImage(
 	return out
 }
 
-func (u *Updater) getArtistImage(ctx context.Context, artist string) string {
+func (u *Updater) storedArtistImage(ctx context.Context, artistURL string) string {
+	stored, err := database.New(u.db).GetArtist(ctx, artistURL)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		u.error(ctx, err, "could not get artist")
+	}
+
+	return stored.ImageUrl.String
+}
+
+// activeYears returns the years of the artist's first and last albums, or 0
+// when no album has a year.
+func activeYears(a scraper.Artist) (int, int) {
+	from, to := 0, 0
+	for _, al := range a.Albums {
+		if al.Year == 0 {
+			continue
+		}
+		if from == 0 || al.Year < from {
+			from = al.Year
+		}
+		to = max(to, al.Year)
+	}
+	return from, to
+}
+
+// getArtistImage returns the best image the providers have for the artist and
+// the external IDs of the best result that has any. Providers that can are
+// told the years the artist released albums in.
+func (u *Updater) getArtistImage(
+	ctx context.Context, artist scraper.Artist,
+) (string, map[string]string) {
 	// TODO: make deadline configuraable
 	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
 	defer cancel()
 	g, ctx := errgroup.WithContext(ctx)
 
 	out := make(chan provider.ArtistResult, len(u.artistProviders))
+	from, to := activeYears(artist)
 
-	for name, provider := range u.artistProviders {
-		name, provider := name, provider
-		if !provider.provider.ArtistEnabled() {
+	for name, p := range u.artistProviders {
+		name, p := name, p
+		if !p.provider.ArtistEnabled() {
 			continue
 		}
 
 		g.Go(func() error {
 			ctx := logging.AddField(ctx, zap.String("provider", name))
-			as, err := provider.provider.SearchArtists(ctx, artist)
+
+			var (
+				as  []provider.ArtistResult
+				err error
+			)
+			if ap, ok := p.provider.(provider.ActiveArtistProvider); ok && from != 0 {
+				as, err = ap.SearchActiveArtists(ctx, artist.Name, from, to)
+			} else {
+				as, err = p.provider.SearchArtists(ctx, artist.Name)
+			}
 			if err != nil {
-				u.error(ctx, err, "could not search artists")
+				u.providerError(ctx, err, "could not search artists")
 				return nil
 			}
 
 			for _, a := range as {
-				a.Confidence *= provider.weight
+				a.Confidence *= p.weight
 				select {
 				case out <- a:
 				case <-ctx.Done():
@@ -86,25 +139,44 @@ func (u *Updater) getArtistImage(ctx context.Context, artist string) string {
 	bestCover := ""
 	bestScore := 0
 
+	var bestIDs map[string]string
+	bestIDsScore := 0
+
 	for a := range out {
+		if len(a.ExternalIDs) != 0 && (a.Confidence > bestIDsScore || bestIDs == nil) {
+			bestIDs = a.ExternalIDs
+			bestIDsScore = a.Confidence
+		}
+		// Some providers return artists without images.
+		if a.ImageURL == "" {
+			continue
+		}
 		if a.Confidence > bestScore || bestCover == "" {
 			bestCover = a.ImageURL
 			bestScore = a.Confidence
 		}
 	}
 
-	return bestCover
+	return bestCover, bestIDs
 }
 
 func (u *Updater) ProcessArtists(
 	ctx context.Context, filterUnchanged bool, ins ...<-chan string,
 ) (<-chan ArtistWithImage, <-chan scraper.Album) {
+	var d *artistDiscovery
+	if u.discoveryDepth > 0 {
+		d = newArtistDiscovery(u.discoveryDepth, u.discoveryPages)
+		ins = d.track(ctx, ins...)
+	}
+
 	jobs := u.readArtistPages(
 		ctx,
-		deduplicateOn(ctx, u.concurrency, func(t string) string { return t }, ins...),
+		d,
+		filterUnchanged,
+		deduplicateOn(ctx, u.concurrency, scraper.ArtistURLKey, ins...),
 	)
 	filteredJobs := filterPageReadJobs[scraper.ArtistPageReader](ctx, u, jobs, filterUnchanged)
-	artists, albums := u.runArtistReadJobs(ctx, filteredJobs)
+	artists, albums := u.runArtistReadJobs(ctx, filterUnchanged, filteredJobs)
 	return u.addArtistImage(ctx, artists), albums
 }
 
@@ -119,8 +191,89 @@ func validateString(s string) sql.NullString {
 	}
 }
 
+// recordBioRevision keeps bio as a new revision of the artist's bio if it
+// differs from the latest one.
+func recordBioRevision(
+	ctx context.Context, q *database.Queries, runID int64, a ArtistWithImage,
+) (bool, error) {
+	bio := validateString(a.Bio)
+	if !bio.Valid {
+		return false, nil
+	}
+
+	added, err := q.InsertBioRevision(ctx, database.InsertBioRevisionParams{
+		ArtistUrl:   a.URL,
+		Bio:         bio.String,
+		BioMarkdown: validateString(a.BioMarkdown),
+		RunId:       sql.NullInt64{Valid: runID != 0, Int64: runID},
+		CreatedAt:   time.Now(),
+	})
+
+	return added > 0, err
+}
+
+// insertArtist upserts the artist and its external IDs and records its bio
+// revisions in the same transaction, so that the revisions always match the
+// stored bio.
+func (u *Updater) insertArtist(ctx context.Context, runID int64, a ArtistWithImage) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := database.New(tx)
+
+	if err := q.InsertStoredBioRevision(ctx, a.URL); err != nil {
+		return fmt.Errorf("could not record stored bio: %w", err)
+	}
+
+	if err := q.UpsertArtist(ctx, database.UpsertArtistParams{
+		Url:         a.URL,
+		Name:        a.Name,
+		Bio:         validateString(a.Bio),
+		BioMarkdown: validateString(a.BioMarkdown),
+		BioLanguage: a.BioLanguage,
+		ImageUrl: sql.NullString{
+			Valid:  a.ImageURL != "",
+			String: a.ImageURL,
+		},
+	}); err != nil {
+		return fmt.Errorf("could not upsert artist: %w", err)
+	}
+
+	for service, id := range a.ExternalIDs {
+		if err := q.UpsertArtistExternalID(ctx, database.UpsertArtistExternalIDParams{
+			ArtistUrl:  a.URL,
+			Service:    service,
+			ExternalId: id,
+		}); err != nil {
+			return fmt.Errorf("could not upsert %s ID: %w", service, err)
+		}
+	}
+
+	changed, err := recordBioRevision(ctx, q, runID, a)
+	if err != nil {
+		return fmt.Errorf("could not record bio revision: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	if changed {
+		logging.GetLogger(ctx).Debug("artist bio changed")
+	}
+
+	return nil
+}
+
+// InsertArtists stores the artists. Bio changes are recorded as revisions
+// against the run with ID runID, a runID of 0 records them outside of any run.
+// Artists stored before revisions were kept get their stored bio as first
+// revision.
 func (u *Updater) InsertArtists(
-	ctx context.Context, in <-chan ArtistWithImage,
+	ctx context.Context, runID int64, in <-chan ArtistWithImage,
 ) <-chan ArtistWithImage {
 	out := make(chan ArtistWithImage, u.concurrency)
 	q := database.New(u.db)
@@ -128,24 +281,110 @@ func (u *Updater) InsertArtists(
 		defer close(out)
 		for a := range in {
 			ctx := logging.AddField(ctx, zap.String("artist", a.URL))
-			if err := q.UpsertArtist(ctx, database.UpsertArtistParams{
-				Url:  a.URL,
-				Name: a.Name,
-				Bio:  validateString(a.Bio),
-				ImageUrl: sql.NullString{
-					Valid:  a.ImageURL != "",
-					String: a.ImageURL,
-				},
-			}); err != nil {
-				u.error(ctx, err, "could not upsert artist")
+			pagePath := a.URL
+
+			artistURL, err := resolveArtistAlias(ctx, q, a.URL)
+			if err != nil {
+				u.error(ctx, err, "could not resolve artist alias")
+				u.contents.fail(pagePath)
+				continue
+			}
+
+			switch {
+			case artistURL != a.URL && artistURL == scraper.ArtistURLKey(a.URL):
+				// Artist URLs used to be stored in lower case, the artist is
+				// moved to the URL of the page that was fetched.
+				logging.GetLogger(ctx).With(zap.String("old-url", artistURL)).
+					Info("artist URL case changed")
+				if err := u.moveArtist(ctx, artistURL, a.URL); err != nil {
+					u.error(ctx, err, "could not move artist")
+					u.contents.fail(pagePath)
+					continue
+				}
+			case artistURL != a.URL:
+				logging.GetLogger(ctx).With(zap.String("canonical-url", artistURL)).
+					Debug("artist page is an alias")
+				a.URL = artistURL
+			default:
+				if err := u.detectArtistMove(ctx, q, a.Artist); err != nil {
+					u.error(ctx, err, "could not detect artist move")
+				}
+			}
+
+			if err := u.insertArtist(ctx, runID, a); err != nil {
+				u.error(ctx, err, "could not insert artist")
+				u.contents.fail(pagePath)
 				continue
 			}
+
+			select {
+			case out <- a:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func (u *Updater) replaceRelatedArtists(
+	ctx context.Context, artistURL string, related []string,
+) error {
+	tx, err := u.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := database.New(tx)
+	if err := q.DeleteRelatedArtists(ctx, artistURL); err != nil {
+		return fmt.Errorf("could not delete related artists: %w", err)
+	}
+
+	for _, r := range related {
+		r, err := resolveArtistAlias(ctx, q, r)
+		if err != nil {
+			return err
+		}
+
+		if err := q.InsertRelatedArtist(ctx, database.InsertRelatedArtistParams{
+			A: artistURL,
+			B: r,
+		}); err != nil {
+			return fmt.Errorf("could not insert related artist '%s': %w", r, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// InsertRelatedArtists writes the related artists of every artist that passes
+// through it. Edges can only be written once both artists exist so they are
+// held until the input is exhausted, edges to artists that are still missing
+// are dropped.
+func (u *Updater) InsertRelatedArtists(
+	ctx context.Context, in <-chan ArtistWithImage,
+) <-chan ArtistWithImage {
+	out := make(chan ArtistWithImage, u.concurrency)
+	go func() {
+		defer close(out)
+		related := map[string][]string{}
+		for a := range in {
+			related[a.URL] = a.RelatedArtists
 			select {
 			case out <- a:
 			case <-ctx.Done():
 				return
 			}
 		}
+
+		for artistURL, rs := range related {
+			ctx := logging.AddField(ctx, zap.String("artist", artistURL))
+			if err := u.replaceRelatedArtists(ctx, artistURL, rs); err != nil {
+				u.error(ctx, err, "could not insert related artists")
+				u.contents.fail(artistURL)
+			}
+		}
 	}()
 	return out
 }
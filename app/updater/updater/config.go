@@ -9,6 +9,7 @@ import (
 	"github.com/waelbendhia/scruffy/app/updater/provider"
 	"github.com/waelbendhia/scruffy/app/updater/rate"
 	"github.com/waelbendhia/scruffy/app/updater/scraper"
+	"github.com/waelbendhia/scruffy/app/updater/snapshot"
 )
 
 type (
@@ -27,8 +28,19 @@ type (
 
 		concurrency int
 
-		errorHook func(error)
-		pageHook  func(*scraper.ScruffyPage)
+		registry *scraper.Registry
+
+		discoveryDepth int
+		discoveryPages int
+
+		snapshotRetention snapshot.RetentionPolicy
+		parseGuard        ParseGuard
+
+		contents *pageContents
+
+		errorHook      func(error)
+		pageHook       func(*scraper.ScruffyPage)
+		quarantineHook func(pagePath, reason string)
 	}
 	UpdaterOption func(*Updater)
 )
@@ -38,10 +50,39 @@ func WithLimiter(l *rate.Limiter) UpdaterOption { return func(u *Updater) { u.li
 func WithConcurrency(c int) UpdaterOption       { return func(u *Updater) { u.concurrency = c } }
 func WithErrorHook(h func(error)) UpdaterOption { return func(u *Updater) { u.errorHook = h } }
 
+func WithPageRegistry(r *scraper.Registry) UpdaterOption {
+	return func(u *Updater) { u.registry = r }
+}
+
+// WithArtistDiscovery makes ProcessArtists follow the links to other artist
+// pages found in the bios it reads, up to maxDepth links away from the pages
+// it was given. At most maxPages pages are discovered per run, a maxPages of 0
+// means no limit.
+func WithArtistDiscovery(maxDepth, maxPages int) UpdaterOption {
+	return func(u *Updater) {
+		u.discoveryDepth = maxDepth
+		u.discoveryPages = maxPages
+	}
+}
+
+func WithSnapshotRetention(p snapshot.RetentionPolicy) UpdaterOption {
+	return func(u *Updater) { u.snapshotRetention = p }
+}
+
 func WithPageHook(h func(*scraper.ScruffyPage)) UpdaterOption {
 	return func(u *Updater) { u.pageHook = h }
 }
 
+func WithParseGuard(g ParseGuard) UpdaterOption {
+	return func(u *Updater) { u.parseGuard = g }
+}
+
+// WithQuarantineHook sets a function called with every page the parse guard
+// quarantines and why.
+func WithQuarantineHook(h func(pagePath, reason string)) UpdaterOption {
+	return func(u *Updater) { u.quarantineHook = h }
+}
+
 func AddAlbumProvider(weight int, p provider.AlbumProvider) UpdaterOption {
 	return func(u *Updater) {
 		if u.albumProviders == nil {
@@ -67,7 +108,12 @@ func AddArtistProvider(weight int, p provider.ArtistProvider) UpdaterOption {
 }
 
 func NewUpdater(db *sql.DB, opts ...UpdaterOption) *Updater {
-	u := &Updater{db: db}
+	u := &Updater{
+		db:                db,
+		snapshotRetention: snapshot.DefaultRetentionPolicy(),
+		parseGuard:        DefaultParseGuard(),
+		contents:          newPageContents(),
+	}
 	for _, opt := range opts {
 		opt(u)
 	}
@@ -87,12 +133,18 @@ func NewUpdater(db *sql.DB, opts ...UpdaterOption) *Updater {
 	if u.concurrency == 0 {
 		u.concurrency = runtime.NumCPU()
 	}
+	if u.registry == nil {
+		u.registry = scraper.DefaultRegistry()
+	}
 	if u.errorHook == nil {
 		u.errorHook = func(error) {}
 	}
 	if u.pageHook == nil {
 		u.pageHook = func(*scraper.ScruffyPage) {}
 	}
+	if u.quarantineHook == nil {
+		u.quarantineHook = func(string, string) {}
+	}
 
 	return u
 }
@@ -0,0 +1,115 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/waelbendhia/scruffy/app/updater/database"
+	"github.com/waelbendhia/scruffy/app/updater/logging"
+	"go.uber.org/zap"
+)
+
+// pageContents tracks what was read from the pages of a run. Content hashes
+// are held until what was read from their page is stored, so that a page whose
+// rows could not be stored is read again by the next run.
+type pageContents struct {
+	lock      sync.Mutex
+	pending   map[string]string
+	unchanged map[string]struct{}
+}
+
+func newPageContents() *pageContents {
+	return &pageContents{pending: map[string]string{}, unchanged: map[string]struct{}{}}
+}
+
+func (c *pageContents) reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.pending = map[string]string{}
+	c.unchanged = map[string]struct{}{}
+}
+
+func (c *pageContents) read(pagePath, hash string, unchanged bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if unchanged {
+		c.unchanged[pagePath] = struct{}{}
+		return
+	}
+	c.pending[pagePath] = hash
+}
+
+// isUnchanged reports whether what was read from the page at pagePath is the
+// same as in the previous run.
+func (c *pageContents) isUnchanged(pagePath string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	_, ok := c.unchanged[pagePath]
+	return ok
+}
+
+// fail drops the content hash of the page at pagePath as some of what was
+// read from it could not be stored.
+func (c *pageContents) fail(pagePath string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.pending, pagePath)
+}
+
+func (c *pageContents) take() map[string]string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	pending := c.pending
+	c.pending = map[string]string{}
+	c.unchanged = map[string]struct{}{}
+	return pending
+}
+
+// skipUnchangedContent compares hash with the content hash of the page at
+// pagePath and reports whether what was read from it should be skipped, which
+// is only the case when it did not change and filterUnchanged is set. Changed
+// hashes are saved by SaveContentHashes once what was read is stored.
+func (u *Updater) skipUnchangedContent(
+	ctx context.Context, filterUnchanged bool, pagePath, hash string,
+) bool {
+	h, err := database.New(u.db).GetUpdateHistory(ctx, pagePath)
+	if err != nil {
+		u.error(ctx, err, "could not get content hash")
+	}
+
+	unchanged := err == nil && h.ContentHash.Valid && h.ContentHash.String == hash
+	if unchanged && filterUnchanged {
+		logging.GetLogger(ctx).With(zap.Error(ErrContentUnchanged)).Debug("skipping")
+		return true
+	}
+
+	u.contents.read(pagePath, hash, unchanged)
+	return false
+}
+
+// SaveContentHashes saves the content hashes of the pages read since the run
+// started whose rows were all stored. It should be called once the run's
+// artists, albums and rankings are inserted, the hashes are dropped if ctx
+// was cancelled before then.
+func (u *Updater) SaveContentHashes(ctx context.Context) error {
+	pending := u.contents.take()
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	var errs []error
+	for pagePath, hash := range pending {
+		err := u.upsertContent(ctx, pagePath, hash)
+		if err != nil && !errors.Is(err, ErrContentUnchanged) {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
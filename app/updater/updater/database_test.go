@@ -0,0 +1,48 @@
+package updater
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const migrationsPath = "../../../packages/database/prisma/migrations"
+
+// newTestDB returns an in-memory database with every migration applied.
+func newTestDB(t *testing.T) *sql.DB {
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=memory&cache=shared", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// The database only lives as long as one of its connections.
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+
+	migrations, err := filepath.Glob(filepath.Join(migrationsPath, "*", "migration.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations found")
+	}
+
+	for _, m := range migrations {
+		stmts, err := os.ReadFile(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(string(stmts)); err != nil {
+			t.Fatalf("could not apply %s: %v", m, err)
+		}
+	}
+
+	return db
+}
@@ -0,0 +1,32 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/waelbendhia/scruffy/app/updater/database"
+	"github.com/waelbendhia/scruffy/app/updater/scraper"
+)
+
+// recordDiagnostics replaces the diagnostics stored for the page at pagePath
+// with d.
+func (u *Updater) recordDiagnostics(
+	ctx context.Context, pagePath string, d *scraper.Diagnostics,
+) error {
+	raw, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("could not encode diagnostics: %w", err)
+	}
+
+	return database.New(u.db).UpsertPageDiagnostics(ctx, database.UpsertPageDiagnosticsParams{
+		PageURL:      pagePath,
+		NameStrategy: validateString(d.NameStrategy),
+		BioStrategy:  validateString(d.BioStrategy),
+		AlbumMisses:  int64(len(d.AlbumMisses)),
+		SkippedRows:  int64(len(d.SkippedRows)),
+		Diagnostics:  string(raw),
+		RecordedAt:   time.Now(),
+	})
+}
@@ -0,0 +1,165 @@
+package updater
+
+import (
+	"context"
+	"sync"
+
+	"github.com/waelbendhia/scruffy/app/updater/logging"
+	"github.com/waelbendhia/scruffy/app/updater/scraper"
+	"go.uber.org/zap"
+)
+
+// artistDiscovery follows the links found on artist pages and feeds the
+// artist pages they point to back into the artist pipeline.
+//
+// The pipeline is a cycle: discovered pages go through deduplicateOn, are
+// fetched, and may lead to more pages. The discovered channel is closed once
+// the original inputs are drained, every page that entered deduplicateOn has
+// been visited and no links are still being sent. Pages are tracked by the key
+// deduplicateOn deduplicates them on.
+type artistDiscovery struct {
+	maxDepth int
+	maxPages int
+
+	lock        sync.Mutex
+	depths      map[string]int
+	visited     map[string]struct{}
+	discovered  int
+	outstanding int
+	inputsDone  bool
+	closed      bool
+	out         chan string
+}
+
+func newArtistDiscovery(maxDepth, maxPages int) *artistDiscovery {
+	return &artistDiscovery{
+		maxDepth: maxDepth,
+		maxPages: maxPages,
+		depths:   map[string]int{},
+		visited:  map[string]struct{}{},
+		out:      make(chan string),
+	}
+}
+
+// track forwards the pages from ins and records them as seeds. The returned
+// channels should be passed to deduplicateOn along with d.out.
+func (d *artistDiscovery) track(ctx context.Context, ins ...<-chan string) []<-chan string {
+	var wg sync.WaitGroup
+	outs := make([]<-chan string, 0, len(ins)+1)
+	for _, in := range ins {
+		in := in
+		out := make(chan string)
+		outs = append(outs, out)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(out)
+			for a := range in {
+				key := scraper.ArtistURLKey(a)
+				d.lock.Lock()
+				if _, ok := d.depths[key]; !ok {
+					d.depths[key] = 0
+				}
+				d.lock.Unlock()
+
+				select {
+				case out <- a:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		d.lock.Lock()
+		d.inputsDone = true
+		d.lock.Unlock()
+		d.maybeClose(ctx)
+	}()
+
+	go func() {
+		<-ctx.Done()
+		d.maybeClose(ctx)
+	}()
+
+	return append(outs, d.out)
+}
+
+// visit marks artistURL as visited and queues the artist pages it links to
+// that are within the depth and page budget.
+func (d *artistDiscovery) visit(ctx context.Context, artistURL string, links []string) {
+	key := scraper.ArtistURLKey(artistURL)
+	d.lock.Lock()
+	d.visited[key] = struct{}{}
+	depth := d.depths[key]
+
+	var next []string
+	if depth < d.maxDepth {
+		for _, l := range links {
+			lkey := scraper.ArtistURLKey(l)
+			if _, ok := d.depths[lkey]; ok {
+				continue
+			}
+			if d.maxPages > 0 && d.discovered >= d.maxPages {
+				logging.GetLogger(ctx).
+					With(zap.String("artist-url", l)).
+					Debug("discovery page budget exhausted")
+				break
+			}
+
+			d.depths[lkey] = depth + 1
+			d.discovered++
+			next = append(next, l)
+		}
+	}
+
+	if len(next) == 0 {
+		d.lock.Unlock()
+		d.maybeClose(ctx)
+		return
+	}
+
+	d.outstanding++
+	d.lock.Unlock()
+
+	// Sending from the page reader would deadlock once deduplicateOn is
+	// blocked on the page reader, so links are sent from their own goroutine.
+	go func() {
+		defer d.maybeClose(ctx)
+		defer func() {
+			d.lock.Lock()
+			d.outstanding--
+			d.lock.Unlock()
+		}()
+
+		for _, l := range next {
+			select {
+			case d.out <- l:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (d *artistDiscovery) maybeClose(ctx context.Context) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.closed || d.outstanding > 0 {
+		return
+	}
+
+	if ctx.Err() == nil && (!d.inputsDone || len(d.visited) < len(d.depths)) {
+		return
+	}
+
+	d.closed = true
+	close(d.out)
+	logging.GetLogger(ctx).
+		With(zap.Int("discovered", d.discovered)).
+		Info("artist discovery done")
+}
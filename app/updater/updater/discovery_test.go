@@ -0,0 +1,158 @@
+package updater
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/waelbendhia/scruffy/app/updater/scraper"
+)
+
+type discoveryTest struct {
+	name     string
+	maxDepth int
+	maxPages int
+	seeds    []string
+	links    map[string][]string
+	// drop are pages that are received but never visited, the context is
+	// cancelled when one of them is received.
+	drop     []string
+	expected []string
+}
+
+// run feeds the pages discovery returns back into it the way ProcessArtists
+// does and checks which pages were received.
+func (dt *discoveryTest) run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	seeds := make(chan string)
+	go func() {
+		defer close(seeds)
+		for _, s := range dt.seeds {
+			seeds <- s
+		}
+	}()
+
+	d := newArtistDiscovery(dt.maxDepth, dt.maxPages)
+
+	var wg sync.WaitGroup
+	pages := make(chan string)
+	for _, out := range d.track(ctx, seeds) {
+		out := out
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for a := range out {
+				pages <- a
+			}
+		}()
+	}
+	go func() { wg.Wait(); close(pages) }()
+
+	var (
+		received []string
+		seen     = map[string]struct{}{}
+		timeout  = time.After(time.Second)
+	)
+	for {
+		select {
+		case a, ok := <-pages:
+			if !ok {
+				slices.Sort(received)
+				if !slices.Equal(received, dt.expected) {
+					t.Errorf("expected pages %v got %v", dt.expected, received)
+				}
+				return
+			}
+
+			if _, ok := seen[scraper.ArtistURLKey(a)]; ok {
+				continue
+			}
+			seen[scraper.ArtistURLKey(a)] = struct{}{}
+			received = append(received, a)
+
+			if slices.Contains(dt.drop, a) {
+				cancel()
+				continue
+			}
+			d.visit(ctx, a, dt.links[a])
+		case <-timeout:
+			t.Fatalf("discovery did not close, received %v", received)
+		}
+	}
+}
+
+func TestArtistDiscovery(t *testing.T) {
+	tts := []discoveryTest{
+		{
+			name:     "depth limit",
+			maxDepth: 1,
+			seeds:    []string{"/vol1/a.html"},
+			links: map[string][]string{
+				"/vol1/a.html": {"/vol1/b.html"},
+				"/vol1/b.html": {"/vol1/c.html"},
+			},
+			expected: []string{"/vol1/a.html", "/vol1/b.html"},
+		},
+		{
+			name:     "deeper",
+			maxDepth: 2,
+			seeds:    []string{"/vol1/a.html"},
+			links: map[string][]string{
+				"/vol1/a.html": {"/vol1/b.html"},
+				"/vol1/b.html": {"/vol1/c.html"},
+				"/vol1/c.html": {"/vol1/d.html"},
+			},
+			expected: []string{"/vol1/a.html", "/vol1/b.html", "/vol1/c.html"},
+		},
+		{
+			name:     "page budget",
+			maxDepth: 3,
+			maxPages: 2,
+			seeds:    []string{"/vol1/a.html"},
+			links: map[string][]string{
+				"/vol1/a.html": {"/vol1/b.html", "/vol1/c.html", "/vol1/d.html"},
+				"/vol1/b.html": {"/vol1/e.html"},
+			},
+			expected: []string{"/vol1/a.html", "/vol1/b.html", "/vol1/c.html"},
+		},
+		{
+			name:     "links back to visited pages",
+			maxDepth: 3,
+			seeds:    []string{"/vol1/a.html", "/vol1/b.html"},
+			links: map[string][]string{
+				"/vol1/a.html": {"/vol1/b.html", "/vol1/c.html"},
+				"/vol1/b.html": {"/vol1/A.html", "/vol1/a.html"},
+				"/vol1/c.html": {"/vol1/a.html", "/vol1/b.html", "/vol1/c.html"},
+			},
+			expected: []string{"/vol1/a.html", "/vol1/b.html", "/vol1/c.html"},
+		},
+		{
+			name:     "inputs close before any visit",
+			maxDepth: 2,
+		},
+		{
+			name:     "seeds without links",
+			maxDepth: 2,
+			seeds:    []string{"/vol1/a.html", "/vol1/b.html"},
+			expected: []string{"/vol1/a.html", "/vol1/b.html"},
+		},
+		{
+			name:     "job dropped without visit",
+			maxDepth: 2,
+			seeds:    []string{"/vol1/a.html"},
+			links: map[string][]string{
+				"/vol1/a.html": {"/vol1/b.html"},
+			},
+			drop:     []string{"/vol1/b.html"},
+			expected: []string{"/vol1/a.html", "/vol1/b.html"},
+		},
+	}
+
+	for _, tt := range tts {
+		t.Run(tt.name, tt.run)
+	}
+}
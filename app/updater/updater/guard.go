@@ -0,0 +1,154 @@
+package updater
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/waelbendhia/scruffy/app/updater/database"
+	"github.com/waelbendhia/scruffy/app/updater/logging"
+	"go.uber.org/zap"
+)
+
+type (
+	// ParseGuard decides which parses look broken. A page is quarantined when
+	// its album count, bio length or link count falls below MinRatio of the
+	// previous parse's. The checks only apply once the previous parse had at
+	// least MinAlbums albums, MinBioLength bytes of bio or MinLinks links, so
+	// small pages can shrink freely. A MinRatio of 0 disables the guard.
+	ParseGuard struct {
+		MinRatio     float64
+		MinAlbums    int
+		MinBioLength int
+		MinLinks     int
+		// MaxQuarantined is the number of pages quarantined in a run after
+		// which the run is aborted, 0 means the run is never aborted.
+		MaxQuarantined int
+	}
+	// pageParse is the size of what was read from a page, fields that do not
+	// apply to the page are invalid.
+	pageParse struct {
+		albums    sql.NullInt64
+		bioLength sql.NullInt64
+		links     sql.NullInt64
+	}
+	guardedRunKey struct{}
+	guardedRun    struct {
+		quarantined atomic.Int64
+		cancel      context.CancelCauseFunc
+	}
+)
+
+var ErrTooManyQuarantined = errors.New("too many pages quarantined")
+
+func DefaultParseGuard() ParseGuard {
+	return ParseGuard{
+		MinRatio:       0.5,
+		MinAlbums:      3,
+		MinBioLength:   500,
+		MinLinks:       20,
+		MaxQuarantined: 25,
+	}
+}
+
+func parseCount(n int) sql.NullInt64 { return sql.NullInt64{Valid: true, Int64: int64(n)} }
+
+func shrunk(prev, next sql.NullInt64, atLeast int, ratio float64) bool {
+	return prev.Valid && next.Valid && prev.Int64 >= int64(atLeast) &&
+		float64(next.Int64) < float64(prev.Int64)*ratio
+}
+
+// check returns why next looks broken compared to prev, or an empty string if
+// it does not.
+func (g ParseGuard) check(prev database.PageParse, next pageParse) string {
+	switch {
+	case g.MinRatio <= 0:
+		return ""
+	case shrunk(prev.Albums, next.albums, g.MinAlbums, g.MinRatio):
+		return fmt.Sprintf("album count dropped from %d to %d", prev.Albums.Int64, next.albums.Int64)
+	case shrunk(prev.BioLength, next.bioLength, g.MinBioLength, g.MinRatio):
+		return fmt.Sprintf("bio length dropped from %d to %d", prev.BioLength.Int64, next.bioLength.Int64)
+	case shrunk(prev.Links, next.links, g.MinLinks, g.MinRatio):
+		return fmt.Sprintf("link count dropped from %d to %d", prev.Links.Int64, next.links.Int64)
+	default:
+		return ""
+	}
+}
+
+// GuardRun returns a context that is cancelled with ErrTooManyQuarantined
+// once more than the guard's MaxQuarantined pages are quarantined by the
+// stages it is passed to.
+func (u *Updater) GuardRun(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	ctx = context.WithValue(ctx, guardedRunKey{}, &guardedRun{cancel: cancel})
+	return ctx, func() { cancel(context.Canceled) }
+}
+
+// guardPage compares next with the last accepted parse of the page at
+// pagePath. Pages that look broken are quarantined and guardPage returns
+// false, otherwise next becomes the page's last accepted parse.
+func (u *Updater) guardPage(ctx context.Context, pagePath string, next pageParse) bool {
+	q := database.New(u.db)
+	now := time.Now()
+
+	prev, err := q.GetPageParse(ctx, pagePath)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		u.error(ctx, err, "could not get previous parse")
+		return true
+	}
+
+	if reason := u.parseGuard.check(prev, next); reason != "" {
+		u.quarantine(ctx, q, pagePath, reason, now)
+		return false
+	}
+
+	if err := q.UpsertPageParse(ctx, database.UpsertPageParseParams{
+		PageURL:   pagePath,
+		Albums:    next.albums,
+		BioLength: next.bioLength,
+		Links:     next.links,
+		ParsedAt:  now,
+	}); err != nil {
+		u.error(ctx, err, "could not record parse")
+	}
+
+	if _, err := q.DeletePageQuarantine(ctx, pagePath); err != nil {
+		u.error(ctx, err, "could not release page from quarantine")
+	}
+
+	return true
+}
+
+func (u *Updater) quarantine(
+	ctx context.Context, q *database.Queries, pagePath, reason string, at time.Time,
+) {
+	logging.GetLogger(ctx).With(zap.String("reason", reason)).Warn("quarantining page")
+
+	if err := q.UpsertPageQuarantine(ctx, database.UpsertPageQuarantineParams{
+		PageURL:       pagePath,
+		Reason:        reason,
+		QuarantinedAt: at,
+	}); err != nil {
+		u.error(ctx, err, "could not quarantine page")
+	}
+
+	// The page is fetched and parsed again on the next update even if it has
+	// not changed by then.
+	if err := q.InvalidateUpdateHistory(ctx, pagePath); err != nil {
+		u.error(ctx, err, "could not invalidate update history")
+	}
+
+	u.quarantineHook(pagePath, reason)
+
+	run, ok := ctx.Value(guardedRunKey{}).(*guardedRun)
+	if !ok || u.parseGuard.MaxQuarantined <= 0 {
+		return
+	}
+	if run.quarantined.Add(1) > int64(u.parseGuard.MaxQuarantined) {
+		u.error(ctx, ErrTooManyQuarantined, "aborting run")
+		run.cancel(ErrTooManyQuarantined)
+	}
+}
@@ -0,0 +1,111 @@
+package updater
+
+import (
+	"testing"
+
+	"github.com/waelbendhia/scruffy/app/updater/database"
+)
+
+type guardTest struct {
+	name       string
+	guard      ParseGuard
+	prev       database.PageParse
+	next       pageParse
+	quarantine bool
+}
+
+func (gt *guardTest) run(t *testing.T) {
+	reason := gt.guard.check(gt.prev, gt.next)
+	if gt.quarantine && reason == "" {
+		t.Errorf("expected parse to be quarantined")
+	} else if !gt.quarantine && reason != "" {
+		t.Errorf("expected parse to be accepted, got '%s'", reason)
+	}
+}
+
+func TestParseGuardCheck(t *testing.T) {
+	guard := DefaultParseGuard()
+
+	tts := []guardTest{
+		{
+			name:       "first parse",
+			guard:      guard,
+			next:       pageParse{albums: parseCount(0)},
+			quarantine: false,
+		},
+		{
+			name:       "albums dropped",
+			guard:      guard,
+			prev:       database.PageParse{Albums: parseCount(10)},
+			next:       pageParse{albums: parseCount(4)},
+			quarantine: true,
+		},
+		{
+			name:       "albums dropped to ratio",
+			guard:      guard,
+			prev:       database.PageParse{Albums: parseCount(10)},
+			next:       pageParse{albums: parseCount(5)},
+			quarantine: false,
+		},
+		{
+			name:       "albums dropped below minimum",
+			guard:      guard,
+			prev:       database.PageParse{Albums: parseCount(2)},
+			next:       pageParse{albums: parseCount(0)},
+			quarantine: false,
+		},
+		{
+			name:       "albums grew",
+			guard:      guard,
+			prev:       database.PageParse{Albums: parseCount(10)},
+			next:       pageParse{albums: parseCount(12)},
+			quarantine: false,
+		},
+		{
+			name:       "bio shrank",
+			guard:      guard,
+			prev:       database.PageParse{Albums: parseCount(5), BioLength: parseCount(2000)},
+			next:       pageParse{albums: parseCount(5), bioLength: parseCount(100)},
+			quarantine: true,
+		},
+		{
+			name:       "short bio shrank",
+			guard:      guard,
+			prev:       database.PageParse{BioLength: parseCount(400)},
+			next:       pageParse{bioLength: parseCount(10)},
+			quarantine: false,
+		},
+		{
+			name:       "links dropped",
+			guard:      guard,
+			prev:       database.PageParse{Links: parseCount(100)},
+			next:       pageParse{links: parseCount(30)},
+			quarantine: true,
+		},
+		{
+			name:       "few links dropped",
+			guard:      guard,
+			prev:       database.PageParse{Links: parseCount(19)},
+			next:       pageParse{links: parseCount(0)},
+			quarantine: false,
+		},
+		{
+			name:       "count not read",
+			guard:      guard,
+			prev:       database.PageParse{Albums: parseCount(10)},
+			next:       pageParse{links: parseCount(100)},
+			quarantine: false,
+		},
+		{
+			name:       "disabled",
+			guard:      ParseGuard{MinRatio: 0, MinAlbums: 3},
+			prev:       database.PageParse{Albums: parseCount(10)},
+			next:       pageParse{albums: parseCount(0)},
+			quarantine: false,
+		},
+	}
+
+	for _, tt := range tts {
+		t.Run(tt.name, tt.run)
+	}
+}
@@ -19,6 +19,7 @@ type (
 	artistsPageReadJob = pageReadJob[scraper.PageReader]
 	artistPageReadJob  = pageReadJob[scraper.ArtistPageReader]
 	ratingsPageReadJob = pageReadJob[scraper.CDReviewReader]
+	rankingPageReadJob = pageReadJob[scraper.RankingReader]
 )
 
 func filterPageReadJobs[T any](
@@ -58,7 +59,7 @@ func filterPageReadJobs[T any](
 }
 
 func (u *Updater) runArtistReadJobs(
-	ctx context.Context, in <-chan artistPageReadJob,
+	ctx context.Context, filterUnchanged bool, in <-chan artistPageReadJob,
 ) (<-chan scraper.Artist, <-chan scraper.Album) {
 	outArtists := make(chan scraper.Artist, u.concurrency)
 	outAlbums := make(chan scraper.Album, u.concurrency)
@@ -72,6 +73,22 @@ func (u *Updater) runArtistReadJobs(
 				continue
 			}
 
+			if err := u.recordDiagnostics(ctx, job.path, &res.Diagnostics); err != nil {
+				u.error(ctx, err, "could not record diagnostics")
+			}
+
+			if !u.guardPage(ctx, job.path, pageParse{
+				albums:    parseCount(len(res.Albums)),
+				bioLength: parseCount(len(res.Bio)),
+			}) {
+				continue
+			}
+
+			hash := scraper.ArtistContentHash(res)
+			if u.skipUnchangedContent(ctx, filterUnchanged, job.path, hash) {
+				continue
+			}
+
 			select {
 			case outArtists <- *res:
 			case <-ctx.Done():
@@ -109,6 +126,10 @@ func (u *Updater) runArtistPageReadJobs(ctx context.Context, in <-chan artistsPa
 				continue
 			}
 
+			if !u.guardPage(ctx, job.path, pageParse{links: parseCount(len(res))}) {
+				continue
+			}
+
 			for a := range res {
 				select {
 				case out <- a:
@@ -126,7 +147,7 @@ func (u *Updater) runArtistPageReadJobs(ctx context.Context, in <-chan artistsPa
 }
 
 func (u *Updater) runRatingsPageReadJobs(
-	ctx context.Context, in <-chan ratingsPageReadJob,
+	ctx context.Context, filterUnchanged bool, in <-chan ratingsPageReadJob,
 ) (<-chan string, <-chan scraper.Album) {
 	outArtist := make(chan string, u.concurrency)
 	outAlbum := make(chan scraper.Album, u.concurrency)
@@ -140,12 +161,25 @@ func (u *Updater) runRatingsPageReadJobs(
 				continue
 			}
 
+			if err := u.recordDiagnostics(ctx, job.path, &res.Diagnostics); err != nil {
+				u.error(ctx, err, "could not record diagnostics")
+			}
+
+			if !u.guardPage(ctx, job.path, pageParse{albums: parseCount(len(res.Albums))}) {
+				continue
+			}
+
+			hash := scraper.CDReviewContentHash(res.Albums)
+			if u.skipUnchangedContent(ctx, filterUnchanged, job.path, hash) {
+				continue
+			}
+
 			as := map[string]struct{}{}
 
 			g, ctx := errgroup.WithContext(ctx)
 
 			g.Go(func() error {
-				for _, a := range res {
+				for _, a := range res.Albums {
 					as[a.ArtistURL] = struct{}{}
 					select {
 					case outAlbum <- a:
@@ -172,3 +206,54 @@ func (u *Updater) runRatingsPageReadJobs(
 
 	return outArtist, outAlbum
 }
+
+func (u *Updater) runRankingPageReadJobs(
+	ctx context.Context, filterUnchanged bool, in <-chan rankingPageReadJob,
+) (<-chan string, <-chan scraper.Ranking) {
+	outArtist := make(chan string, u.concurrency)
+	outRanking := make(chan scraper.Ranking, u.concurrency)
+
+	u.doConcurrently(ctx, func() { close(outRanking); close(outArtist) }, func() error {
+		for job := range in {
+			ctx := logging.AddField(ctx, zap.String("page-path", job.path))
+			res, err := job.reader(ctx, job.page.Doc)
+			if err != nil {
+				u.error(ctx, err, "could not read page")
+				continue
+			}
+
+			if err := u.recordDiagnostics(ctx, job.path, &res.Diagnostics); err != nil {
+				u.error(ctx, err, "could not record diagnostics")
+			}
+
+			if !u.guardPage(ctx, job.path, pageParse{albums: parseCount(len(res.Entries))}) {
+				continue
+			}
+
+			hash := scraper.RankingContentHash(res)
+			if u.skipUnchangedContent(ctx, filterUnchanged, job.path, hash) {
+				continue
+			}
+
+			// The ranked artists are read so their albums are stored by the
+			// time the ranking is.
+			for _, e := range res.Entries {
+				select {
+				case outArtist <- e.ArtistURL:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+
+			select {
+			case outRanking <- *res:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		return nil
+	})
+
+	return outArtist, outRanking
+}
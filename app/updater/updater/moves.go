@@ -0,0 +1,189 @@
+package updater
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/waelbendhia/scruffy/app/updater/database"
+	"github.com/waelbendhia/scruffy/app/updater/logging"
+	"github.com/waelbendhia/scruffy/app/updater/scraper"
+	"go.uber.org/zap"
+)
+
+// resolveArtistAlias returns the URL the artist at artistURL moved to, or
+// artistURL if it did not move.
+func resolveArtistAlias(
+	ctx context.Context, q *database.Queries, artistURL string,
+) (string, error) {
+	canonical, err := q.GetArtistAlias(ctx, artistURL)
+	if err == nil {
+		return canonical, nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("could not get artist alias: %w", err)
+	}
+
+	// Links to the artist may spell its URL in a different case.
+	stored, err := q.GetArtistURLByKey(ctx, artistURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		return artistURL, nil
+	} else if err != nil {
+		return "", fmt.Errorf("could not get artist: %w", err)
+	}
+
+	return stored, nil
+}
+
+// moveArtist moves the artist at oldURL to newURL along with its albums and
+// related artists, keeping the edits made to them. oldURL is kept as an alias
+// of newURL.
+func (u *Updater) moveArtist(ctx context.Context, oldURL, newURL string) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := database.New(tx)
+
+	// The artist may be moving back to a URL it used to have.
+	if err := q.DeleteArtistAlias(ctx, newURL); err != nil {
+		return fmt.Errorf("could not delete artist alias: %w", err)
+	}
+
+	if err := q.MoveArtist(ctx, database.MoveArtistParams{
+		NewUrl: newURL,
+		OldUrl: oldURL,
+	}); err != nil {
+		return fmt.Errorf("could not move artist: %w", err)
+	}
+
+	// Albums already stored at the new URL were read from ratings pages
+	// during this update, the moved ones carry the edits.
+	names, err := q.ListArtistAlbumNames(ctx, oldURL)
+	if err != nil {
+		return fmt.Errorf("could not list moved albums: %w", err)
+	}
+
+	if err := q.DeleteArtistAlbumsNamed(ctx, database.DeleteArtistAlbumsNamedParams{
+		ArtistUrl: newURL,
+		Names:     names,
+	}); err != nil {
+		return fmt.Errorf("could not delete conflicting albums: %w", err)
+	}
+
+	if err := q.MoveArtistAlbums(ctx, database.MoveArtistAlbumsParams{
+		NewUrl: newURL,
+		OldUrl: oldURL,
+	}); err != nil {
+		return fmt.Errorf("could not move albums: %w", err)
+	}
+
+	if err := q.MoveRatingHistory(ctx, database.MoveRatingHistoryParams{
+		NewUrl: newURL,
+		OldUrl: oldURL,
+	}); err != nil {
+		return fmt.Errorf("could not move rating history: %w", err)
+	}
+
+	if err := q.MoveBioRevisions(ctx, database.MoveBioRevisionsParams{
+		NewUrl: newURL,
+		OldUrl: oldURL,
+	}); err != nil {
+		return fmt.Errorf("could not move bio revisions: %w", err)
+	}
+
+	if err := q.MoveArtistExternalIDs(ctx, database.MoveArtistExternalIDsParams{
+		NewUrl: newURL,
+		OldUrl: oldURL,
+	}); err != nil {
+		return fmt.Errorf("could not move external IDs: %w", err)
+	}
+
+	if err := q.MoveRankingEntries(ctx, database.MoveRankingEntriesParams{
+		NewUrl: sql.NullString{Valid: true, String: newURL},
+		OldUrl: sql.NullString{Valid: true, String: oldURL},
+	}); err != nil {
+		return fmt.Errorf("could not move ranking entries: %w", err)
+	}
+
+	aliases, err := q.ListArtistAlbumAliases(ctx, oldURL)
+	if err != nil {
+		return fmt.Errorf("could not list moved album aliases: %w", err)
+	}
+
+	if err := q.DeleteArtistAlbumAliases(ctx, database.DeleteArtistAlbumAliasesParams{
+		ArtistUrl: newURL,
+		Aliases:   aliases,
+	}); err != nil {
+		return fmt.Errorf("could not delete conflicting album aliases: %w", err)
+	}
+
+	if err := q.MoveAlbumAliases(ctx, database.MoveAlbumAliasesParams{
+		NewUrl: newURL,
+		OldUrl: oldURL,
+	}); err != nil {
+		return fmt.Errorf("could not move album aliases: %w", err)
+	}
+
+	if err := q.MoveRelatedArtists(ctx, database.MoveRelatedArtistsParams{
+		OldUrl: oldURL,
+		NewUrl: newURL,
+	}); err != nil {
+		return fmt.Errorf("could not move related artists: %w", err)
+	}
+
+	// Edges that already existed for the new URL were not moved.
+	if err := q.DeleteArtistEdges(ctx, oldURL); err != nil {
+		return fmt.Errorf("could not delete related artists: %w", err)
+	}
+
+	if err := q.MoveArtistAliases(ctx, database.MoveArtistAliasesParams{
+		NewUrl: newURL,
+		OldUrl: oldURL,
+	}); err != nil {
+		return fmt.Errorf("could not move artist aliases: %w", err)
+	}
+
+	if err := q.UpsertArtistAlias(ctx, database.UpsertArtistAliasParams{
+		Alias: oldURL,
+		Url:   newURL,
+	}); err != nil {
+		return fmt.Errorf("could not insert artist alias: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// detectArtistMove moves the stored artist with the same name and bio as a to
+// a's URL when a is not stored yet.
+func (u *Updater) detectArtistMove(
+	ctx context.Context, q *database.Queries, a scraper.Artist,
+) error {
+	if a.Bio == "" {
+		return nil
+	}
+
+	_, err := q.GetArtist(ctx, a.URL)
+	if err == nil {
+		return nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("could not get artist: %w", err)
+	}
+
+	oldURL, err := q.FindMovedArtist(ctx, database.FindMovedArtistParams{
+		Name: a.Name,
+		Bio:  validateString(a.Bio),
+		Url:  a.URL,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("could not find moved artist: %w", err)
+	}
+
+	logging.GetLogger(ctx).With(zap.String("old-url", oldURL)).Info("artist moved")
+
+	return u.moveArtist(ctx, oldURL, a.URL)
+}
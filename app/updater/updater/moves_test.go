@@ -0,0 +1,262 @@
+package updater
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/waelbendhia/scruffy/app/updater/database"
+	"github.com/waelbendhia/scruffy/app/updater/scraper"
+)
+
+const (
+	movedArtistName = "Captain Beefheart"
+	movedArtistBio  = "Don Van Vliet was a painter."
+	relatedArtist   = "/vol2/zappa.html"
+)
+
+// seedMovedArtist stores the artist at artistURL with a row in every table
+// that refers to it. The artist was found at each of formerURLs before, and
+// the album "Safe As Milk" was already read for newURL from a ratings page.
+func seedMovedArtist(
+	t *testing.T, db *sql.DB, artistURL, newURL string, formerURLs ...string,
+) {
+	stmts := []struct {
+		query string
+		args  []any
+	}{
+		{
+			`INSERT INTO "Artist" ("url", "name", "bio", "lastModified")
+			VALUES (?1, ?2, ?3, DATETIME('now')), (?4, 'Frank Zappa', NULL, DATETIME('now'))`,
+			[]any{artistURL, movedArtistName, movedArtistBio, relatedArtist},
+		},
+		{
+			`INSERT INTO "Album" ("name", "rating", "artistUrl", "pageURL", "imageUrl")
+			VALUES ('Trout Mask Replica', 10, ?1, ?1, 'edited-trout.jpg'),
+				('Safe As Milk', 7, ?1, ?1, 'edited-milk.jpg'),
+				('Safe As Milk', 7.5, ?2, '/cdreview/1967.html', NULL)`,
+			[]any{artistURL, newURL},
+		},
+		{
+			`INSERT INTO "RatingHistory" ("artistUrl", "albumName", "oldRating", "newRating", "pageURL", "changedAt")
+			VALUES (?1, 'Trout Mask Replica', 9, 10, ?1, DATETIME('now'))`,
+			[]any{artistURL},
+		},
+		{
+			`INSERT INTO "BioRevision" ("artistUrl", "bio", "createdAt")
+			VALUES (?1, ?2, DATETIME('now'))`,
+			[]any{artistURL, movedArtistBio},
+		},
+		{
+			`INSERT INTO "ArtistExternalID" ("artistUrl", "service", "externalId")
+			VALUES (?1, 'discogs', '22082')`,
+			[]any{artistURL},
+		},
+		{
+			`INSERT INTO "Ranking" ("pageURL", "title", "lastModified")
+			VALUES ('/music/best100.html', 'The best rock albums', DATETIME('now'))`,
+			nil,
+		},
+		{
+			`INSERT INTO "RankingEntry"
+				("rankingUrl", "position", "rank", "artistName", "albumTitle", "artistLink", "artistUrl", "albumName")
+			VALUES ('/music/best100.html', 1, 1, ?2, 'Trout Mask Replica', ?1, ?1, 'Trout Mask Replica')`,
+			[]any{artistURL, movedArtistName},
+		},
+		{
+			`INSERT INTO "AlbumAlias" ("artistUrl", "alias", "normalized", "name")
+			VALUES (?1, 'Trout Mask Replica: The Deluxe Edition', 'trout mask replica', 'Trout Mask Replica'),
+				(?1, 'Safe as Milk', 'safe as milk', 'Safe As Milk'),
+				(?2, 'Safe as Milk', 'safe as milk', 'Safe As Milk')`,
+			[]any{artistURL, newURL},
+		},
+		{
+			// The edge from newURL was read during this update.
+			`INSERT INTO "_RelatedArtists" ("A", "B") VALUES (?1, ?3), (?3, ?1), (?2, ?3)`,
+			[]any{artistURL, newURL, relatedArtist},
+		},
+	}
+	for _, f := range formerURLs {
+		stmts = append(stmts, struct {
+			query string
+			args  []any
+		}{`INSERT INTO "ArtistAlias" ("alias", "url") VALUES (?1, ?2)`, []any{f, artistURL}})
+	}
+
+	for _, s := range stmts {
+		if _, err := db.Exec(s.query, s.args...); err != nil {
+			t.Fatalf("could not seed '%s': %v", s.query, err)
+		}
+	}
+}
+
+// countRows returns the number of rows of the query, which takes an artist
+// URL.
+func countRows(t *testing.T, db *sql.DB, query, artistURL string) int {
+	var n int
+	if err := db.QueryRow(query, artistURL).Scan(&n); err != nil {
+		t.Fatalf("could not run '%s': %v", query, err)
+	}
+	return n
+}
+
+type moveTest struct {
+	name       string
+	from       string
+	to         string
+	formerURLs []string
+	// detect moves the artist through detectArtistMove with bio instead of
+	// calling moveArtist.
+	detect bool
+	bio    string
+	moved  bool
+}
+
+func (mt *moveTest) run(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	u := NewUpdater(db)
+	q := database.New(db)
+
+	seedMovedArtist(t, db, mt.from, mt.to, mt.formerURLs...)
+
+	var err error
+	if mt.detect {
+		err = u.detectArtistMove(ctx, q, scraper.Artist{URL: mt.to, Name: movedArtistName, Bio: mt.bio})
+	} else {
+		err = u.moveArtist(ctx, mt.from, mt.to)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A former URL the artist moves back to is no longer an alias.
+	aliases := 1
+	for _, f := range mt.formerURLs {
+		if f != mt.to {
+			aliases++
+		}
+	}
+
+	stayed, followed := mt.from, mt.to
+
+	counts := []struct {
+		query string
+		// expected is the number of rows once the artist moved, none are
+		// left for the URL it moved from.
+		expected int
+	}{
+		{`SELECT COUNT(*) FROM "Artist" WHERE "url" = ?`, 1},
+		{`SELECT COUNT(*) FROM "Album" WHERE "artistUrl" = ?`, 2},
+		{`SELECT COUNT(*) FROM "RatingHistory" WHERE "artistUrl" = ?`, 1},
+		{`SELECT COUNT(*) FROM "BioRevision" WHERE "artistUrl" = ?`, 1},
+		{`SELECT COUNT(*) FROM "ArtistExternalID" WHERE "artistUrl" = ?`, 1},
+		{`SELECT COUNT(*) FROM "RankingEntry" WHERE "artistUrl" = ?`, 1},
+		{`SELECT COUNT(*) FROM "AlbumAlias" WHERE "artistUrl" = ?`, 2},
+		{`SELECT COUNT(*) FROM "_RelatedArtists" WHERE "A" = ? OR "B" = ?1`, 2},
+		{`SELECT COUNT(*) FROM "ArtistAlias" WHERE "url" = ?`, aliases},
+	}
+
+	if !mt.moved {
+		// Nothing moved, the rows read for the new URL are left alone.
+		if n := countRows(t, db, counts[0].query, mt.to); n != 0 {
+			t.Errorf("expected no artist at '%s' got %d", mt.to, n)
+		}
+		if n := countRows(t, db, counts[1].query, mt.from); n != 2 {
+			t.Errorf("expected the artist's albums to stay got %d", n)
+		}
+		return
+	}
+
+	for _, c := range counts {
+		if n := countRows(t, db, c.query, stayed); n != 0 {
+			t.Errorf("expected no rows for '%s' in '%s' got %d", stayed, c.query, n)
+		}
+		if n := countRows(t, db, c.query, followed); n != c.expected {
+			t.Errorf("expected %d rows for '%s' in '%s' got %d", c.expected, followed, c.query, n)
+		}
+	}
+
+	// The albums moved with the edits made to them.
+	for name, image := range map[string]string{
+		"Trout Mask Replica": "edited-trout.jpg",
+		"Safe As Milk":       "edited-milk.jpg",
+	} {
+		a, err := q.GetAlbum(ctx, database.GetAlbumParams{ArtistUrl: followed, Name: name})
+		if err != nil {
+			t.Fatalf("could not get '%s': %v", name, err)
+		}
+		if a.ImageUrl.String != image || a.PageURL != followed {
+			t.Errorf("expected '%s' to keep its edits got %+v", name, a)
+		}
+	}
+
+	entry := struct{ artistURL, albumName sql.NullString }{}
+	if err := db.QueryRow(
+		`SELECT "artistUrl", "albumName" FROM "RankingEntry" WHERE "rankingUrl" = '/music/best100.html'`,
+	).Scan(&entry.artistURL, &entry.albumName); err != nil {
+		t.Fatal(err)
+	}
+	if entry.artistURL.String != followed || entry.albumName.String != "Trout Mask Replica" {
+		t.Errorf("expected the ranking entry to follow the album got %+v", entry)
+	}
+
+	if n := countRows(t, db, `SELECT COUNT(*) FROM "ArtistAlias" WHERE "alias" = ?`, followed); n != 0 {
+		t.Errorf("expected '%s' not to be an alias of itself", followed)
+	}
+
+	for _, former := range append([]string{mt.from}, mt.formerURLs...) {
+		resolved, err := resolveArtistAlias(ctx, q, former)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resolved != followed {
+			t.Errorf("expected '%s' to resolve to '%s' got '%s'", former, followed, resolved)
+		}
+	}
+}
+
+func TestMoveArtist(t *testing.T) {
+	tts := []moveTest{
+		{
+			name:       "moved",
+			from:       "/vol3/beefheart.html",
+			to:         "/vol2/beefheart.html",
+			formerURLs: []string{"/vol1/beefhear.html"},
+			moved:      true,
+		},
+		{
+			name:  "case changed",
+			from:  "/vol2/captainbeefheart.html",
+			to:    "/vol2/CaptainBeefheart.html",
+			moved: true,
+		},
+		{
+			name:       "moved back to a former URL",
+			from:       "/vol3/beefheart.html",
+			to:         "/vol2/beefheart.html",
+			formerURLs: []string{"/vol2/beefheart.html", "/vol1/beefhear.html"},
+			moved:      true,
+		},
+		{
+			name:   "detected on name and bio",
+			from:   "/vol3/beefheart.html",
+			to:     "/vol2/beefheart.html",
+			detect: true,
+			bio:    movedArtistBio,
+			moved:  true,
+		},
+		{
+			name:   "bio differs",
+			from:   "/vol3/beefheart.html",
+			to:     "/vol2/beefheart.html",
+			detect: true,
+			bio:    "Don Van Vliet was a singer.",
+			moved:  false,
+		},
+	}
+
+	for _, tt := range tts {
+		t.Run(tt.name, tt.run)
+	}
+}
@@ -0,0 +1,167 @@
+package updater
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/waelbendhia/scruffy/app/updater/database"
+	"github.com/waelbendhia/scruffy/app/updater/logging"
+	"github.com/waelbendhia/scruffy/app/updater/scraper"
+	"go.uber.org/zap"
+)
+
+// linkRankingEntry returns the artist and album rows e points to, they are
+// invalid when the artist or album is not stored.
+func linkRankingEntry(
+	ctx context.Context, q *database.Queries, e scraper.RankingEntry,
+) (sql.NullString, sql.NullString, error) {
+	var artistURL, albumName sql.NullString
+
+	url, err := resolveArtistAlias(ctx, q, e.ArtistURL)
+	if err != nil {
+		return artistURL, albumName, err
+	}
+
+	if _, err := q.GetArtist(ctx, url); errors.Is(err, sql.ErrNoRows) {
+		return artistURL, albumName, nil
+	} else if err != nil {
+		return artistURL, albumName, fmt.Errorf("could not get artist: %w", err)
+	}
+	artistURL = sql.NullString{Valid: true, String: url}
+
+	name, err := resolveAlbumName(ctx, q, url, e.AlbumName)
+	if err != nil {
+		return artistURL, albumName, err
+	}
+
+	if _, err := q.GetAlbum(ctx, database.GetAlbumParams{
+		ArtistUrl: url,
+		Name:      name,
+	}); errors.Is(err, sql.ErrNoRows) {
+		return artistURL, albumName, nil
+	} else if err != nil {
+		return artistURL, albumName, fmt.Errorf("could not get album: %w", err)
+	}
+	albumName = sql.NullString{Valid: true, String: name}
+
+	return artistURL, albumName, nil
+}
+
+func (u *Updater) insertRanking(ctx context.Context, r scraper.Ranking) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := database.New(tx)
+
+	if err := q.UpsertRanking(ctx, database.UpsertRankingParams{
+		PageURL: r.PageURL,
+		Title:   r.Title,
+	}); err != nil {
+		return fmt.Errorf("could not upsert ranking: %w", err)
+	}
+
+	if err := q.DeleteRankingEntries(ctx, r.PageURL); err != nil {
+		return fmt.Errorf("could not delete ranking entries: %w", err)
+	}
+
+	for i, e := range r.Entries {
+		artistURL, albumName, err := linkRankingEntry(ctx, q, e)
+		if err != nil {
+			return fmt.Errorf("could not link entry %d: %w", i+1, err)
+		}
+
+		if err := q.InsertRankingEntry(ctx, database.InsertRankingEntryParams{
+			RankingUrl: r.PageURL,
+			Position:   int64(i + 1),
+			Rank:       int64(e.Rank),
+			ArtistName: e.ArtistName,
+			AlbumTitle: e.AlbumName,
+			Year:       sql.NullInt64{Valid: e.Year != 0, Int64: int64(e.Year)},
+			ArtistLink: sql.NullString{Valid: true, String: e.ArtistURL},
+			ArtistUrl:  artistURL,
+			AlbumName:  albumName,
+		}); err != nil {
+			return fmt.Errorf("could not insert entry %d: %w", i+1, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// relinkRankingEntries links the stored entries whose artist or album was
+// missing when they were inserted, they may have been stored since.
+func (u *Updater) relinkRankingEntries(ctx context.Context) error {
+	q := database.New(u.db)
+
+	es, err := q.ListUnlinkedRankingEntries(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list unlinked ranking entries: %w", err)
+	}
+
+	linked := 0
+	for _, e := range es {
+		artistURL, albumName, err := linkRankingEntry(ctx, q, scraper.RankingEntry{
+			ArtistURL: e.ArtistLink.String,
+			AlbumName: e.AlbumTitle,
+		})
+		if err != nil {
+			return fmt.Errorf("could not link entry %d of '%s': %w", e.Position, e.RankingUrl, err)
+		}
+		if artistURL == e.ArtistUrl && albumName == e.AlbumName {
+			continue
+		}
+
+		if err := q.LinkRankingEntry(ctx, database.LinkRankingEntryParams{
+			ArtistUrl:  artistURL,
+			AlbumName:  albumName,
+			RankingUrl: e.RankingUrl,
+			Position:   e.Position,
+		}); err != nil {
+			return fmt.Errorf("could not link entry %d of '%s': %w", e.Position, e.RankingUrl, err)
+		}
+		linked++
+	}
+
+	logging.GetLogger(ctx).With(zap.Int("count", linked)).Info("relinked ranking entries")
+
+	return nil
+}
+
+// InsertRankings stores the rankings, replacing the entries they had. Entries
+// are linked to the artists and albums they name that are stored, so rankings
+// should be inserted once the run's artists and albums are. The entries of
+// rankings that were not read again are linked too once the input is
+// exhausted.
+func (u *Updater) InsertRankings(
+	ctx context.Context, in <-chan scraper.Ranking,
+) <-chan scraper.Ranking {
+	out := make(chan scraper.Ranking, u.concurrency)
+	go func() {
+		defer close(out)
+		for r := range in {
+			ctx := logging.AddField(ctx, zap.String("ranking-url", r.PageURL))
+
+			if err := u.insertRanking(ctx, r); err != nil {
+				u.error(ctx, err, "could not insert ranking")
+				u.contents.fail(r.PageURL)
+				continue
+			}
+
+			select {
+			case out <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if err := u.relinkRankingEntries(ctx); err != nil {
+			u.error(ctx, err, "could not relink ranking entries")
+		}
+	}()
+	return out
+}
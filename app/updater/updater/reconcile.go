@@ -0,0 +1,86 @@
+package updater
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/waelbendhia/scruffy/app/updater/database"
+	"github.com/waelbendhia/scruffy/app/updater/logging"
+	"go.uber.org/zap"
+)
+
+// seenNow is the time stored in the columns reconciliation compares. They are
+// all in UTC so that they compare as text.
+func seenNow() sql.NullTime { return sql.NullTime{Valid: true, Time: time.Now().UTC()} }
+
+func (u *Updater) markArtistNotFound(ctx context.Context, artistURL string) {
+	err := database.New(u.db).MarkArtistNotFound(ctx, database.MarkArtistNotFoundParams{
+		NotFoundAt: seenNow(),
+		Url:        artistURL,
+	})
+	if err != nil {
+		u.error(ctx, err, "could not mark artist as not found")
+	}
+}
+
+// Reconcile tombstones what a full update that started at since did not find
+// anymore: the artists whose page was not found, along with their albums, and
+// the albums that are gone from the page they were read from. Albums are only
+// tombstoned when other albums were read from their page during the update,
+// so a page that failed to be read does not lose its albums. Tombstoned
+// artists and albums are restored once an update stores them again.
+func (u *Updater) Reconcile(ctx context.Context, since time.Time) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := database.New(tx)
+	deletedAt := seenNow()
+	sinceTime := sql.NullTime{Valid: true, Time: since.UTC()}
+
+	artists, err := q.TombstoneNotFoundArtists(ctx, database.TombstoneNotFoundArtistsParams{
+		DeletedAt: deletedAt,
+		Since:     sinceTime,
+	})
+	if err != nil {
+		return fmt.Errorf("could not tombstone artists: %w", err)
+	}
+
+	artistAlbums, err := q.TombstoneArtistAlbums(ctx, deletedAt)
+	if err != nil {
+		return fmt.Errorf("could not tombstone albums of tombstoned artists: %w", err)
+	}
+
+	pages, err := q.ListSeenAlbumPages(ctx, sinceTime)
+	if err != nil {
+		return fmt.Errorf("could not list album pages: %w", err)
+	}
+
+	var missingAlbums int64
+	for _, page := range pages {
+		n, err := q.TombstoneMissingAlbums(ctx, database.TombstoneMissingAlbumsParams{
+			DeletedAt: deletedAt,
+			PageURL:   page,
+			Since:     sinceTime,
+		})
+		if err != nil {
+			return fmt.Errorf("could not tombstone missing albums: %w", err)
+		}
+		missingAlbums += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	logging.GetLogger(ctx).With(
+		zap.Int64("artists", artists),
+		zap.Int64("albums", artistAlbums+missingAlbums),
+	).Info("tombstoned removed artists and albums")
+
+	return nil
+}
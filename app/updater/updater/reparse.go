@@ -0,0 +1,262 @@
+package updater
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/waelbendhia/scruffy/app/updater/database"
+	"github.com/waelbendhia/scruffy/app/updater/logging"
+	"github.com/waelbendhia/scruffy/app/updater/scraper"
+	"github.com/waelbendhia/scruffy/app/updater/snapshot"
+	"go.uber.org/zap"
+)
+
+var ErrNoSnapshot = errors.New("page has no snapshot")
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *Updater) readSnapshot(
+	ctx context.Context, q *database.Queries, pagePath string,
+) (*scraper.ScruffyPage, error) {
+	h, err := q.GetUpdateHistory(ctx, pagePath)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !h.SnapshotHash.Valid) {
+		return nil, fmt.Errorf("'%s': %w", pagePath, ErrNoSnapshot)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not get update history: %w", err)
+	}
+
+	raw, err := snapshot.Load(ctx, q, h.SnapshotHash.String)
+	if err != nil {
+		return nil, err
+	}
+
+	return scraper.NewPage(raw, "", h.CheckedOn)
+}
+
+// LoadPage reads the page at pagePath from its snapshot. If the page has no
+// snapshot and fetch is set, it is fetched with the updater's client and
+// limiter instead.
+func (u *Updater) LoadPage(
+	ctx context.Context, pagePath string, fetch bool,
+) (*scraper.ScruffyPage, error) {
+	page, err := u.readSnapshot(ctx, database.New(u.db), pagePath)
+	if !fetch || !(errors.Is(err, ErrNoSnapshot) || errors.Is(err, snapshot.ErrSnapshotNotFound)) {
+		return page, err
+	}
+
+	page, err = u.getPage(ctx, pagePath)
+	if errors.Is(err, ErrPageUnchanged) {
+		return page, nil
+	}
+
+	return page, err
+}
+
+// readSnapshotPages dispatches the stored pages matching prefixes to their
+// readers. Artist pages are not read here but returned as URLs so they can be
+// deduplicated with the artists found on index and ratings pages.
+func (u *Updater) readSnapshotPages(
+	ctx context.Context, prefixes []string,
+) (<-chan string, <-chan artistsPageReadJob, <-chan ratingsPageReadJob, <-chan rankingPageReadJob) {
+	outArtist := make(chan string, u.concurrency)
+	outArtists := make(chan artistsPageReadJob, u.concurrency)
+	outRatings := make(chan ratingsPageReadJob, u.concurrency)
+	outRankings := make(chan rankingPageReadJob, u.concurrency)
+
+	go func() {
+		defer close(outArtist)
+		defer close(outArtists)
+		defer close(outRatings)
+		defer close(outRankings)
+
+		q := database.New(u.db)
+		pages, err := q.ListSnapshotPages(ctx)
+		if err != nil {
+			u.error(ctx, err, "could not list page snapshots")
+			return
+		}
+
+		for _, p := range pages {
+			if !hasAnyPrefix(p.PageURL, prefixes) {
+				continue
+			}
+
+			ctx := logging.AddField(ctx, zap.String("page-path", p.PageURL))
+			if scraper.IsArtistURL(p.PageURL) {
+				select {
+				case outArtist <- p.PageURL:
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+
+			pageReader, isIndex := u.registry.PageReader(p.PageURL)
+			ratingsReader, isRatings := u.registry.CDReviewReader(p.PageURL)
+			rankingReader, isRanking := u.registry.RankingReader(p.PageURL)
+			if !isIndex && !isRatings && !isRanking {
+				continue
+			}
+
+			page, err := u.readSnapshot(ctx, q, p.PageURL)
+			if err != nil {
+				u.error(ctx, err, "could not read snapshot")
+				continue
+			}
+
+			if isIndex {
+				select {
+				case outArtists <- artistsPageReadJob{page: page, path: p.PageURL, reader: pageReader}:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if isRatings {
+				select {
+				case outRatings <- ratingsPageReadJob{page: page, path: p.PageURL, reader: ratingsReader}:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if isRanking {
+				select {
+				case outRankings <- rankingPageReadJob{page: page, path: p.PageURL, reader: rankingReader}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return outArtist, outArtists, outRatings, outRankings
+}
+
+func (u *Updater) readArtistSnapshots(
+	ctx context.Context, in <-chan string,
+) <-chan artistPageReadJob {
+	out := make(chan artistPageReadJob, u.concurrency)
+	q := database.New(u.db)
+
+	u.doConcurrently(ctx, func() { close(out) }, func() error {
+		for a := range in {
+			ctx := logging.AddField(ctx, zap.String("artist-url", a))
+			page, err := u.readSnapshot(ctx, q, a)
+			if errors.Is(err, ErrNoSnapshot) {
+				logging.GetLogger(ctx).Debug("skipping artist without snapshot")
+				continue
+			} else if err != nil {
+				u.error(ctx, err, "could not read artist snapshot")
+				continue
+			}
+
+			select {
+			case out <- artistPageReadJob{
+				page:   page,
+				path:   a,
+				reader: scraper.ReadArtistFromPage,
+			}:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		return nil
+	})
+
+	return out
+}
+
+// addStoredArtistImage keeps the image the artist already has instead of
+// asking the providers for one.
+func (u *Updater) addStoredArtistImage(
+	ctx context.Context, in <-chan scraper.Artist,
+) <-chan ArtistWithImage {
+	out := make(chan ArtistWithImage, u.concurrency)
+
+	u.doConcurrently(ctx, func() { close(out) }, func() error {
+		for a := range in {
+			select {
+			case out <- ArtistWithImage{Artist: a, ImageURL: u.storedArtistImage(ctx, a.URL)}:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		return nil
+	})
+
+	return out
+}
+
+// addStoredAlbumCover keeps the cover and year the album already has instead
+// of asking the providers for them.
+func (u *Updater) addStoredAlbumCover(
+	ctx context.Context, in <-chan scraper.Album,
+) <-chan AlbumWithImage {
+	out := make(chan AlbumWithImage, u.concurrency)
+
+	u.doConcurrently(ctx, func() { close(out) }, func() error {
+		for a := range in {
+			cover, year := u.storedAlbumCoverAndYear(ctx, a)
+			a.Year = selectNonEmpty(a.Year, year)
+			select {
+			case out <- AlbumWithImage{Album: a, CoverURL: cover}:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		return nil
+	})
+
+	return out
+}
+
+// ReparseSnapshots runs the page readers over the stored snapshots of the
+// pages whose path starts with one of prefixes, or of every page when none are
+// given. Artists listed on matching index and ratings pages are reparsed too.
+// Nothing is fetched, artists and albums keep the images they already have.
+// The results are meant to go through InsertArtists, InsertAlbums and
+// InsertRankings.
+func (u *Updater) ReparseSnapshots(
+	ctx context.Context, prefixes ...string,
+) (<-chan ArtistWithImage, <-chan AlbumWithImage, <-chan scraper.Ranking) {
+	artistURLs, artistsJobs, ratingsJobs, rankingJobs := u.readSnapshotPages(ctx, prefixes)
+
+	// Reparsed pages are never skipped, their content hashes are still
+	// recorded so the next update compares against the current readers.
+	artistsFromRatingsPage, albumsFromRatingsPage := u.runRatingsPageReadJobs(ctx, false, ratingsJobs)
+	artistsFromRankingsPage, rankings := u.runRankingPageReadJobs(ctx, false, rankingJobs)
+
+	artists, albums := u.runArtistReadJobs(ctx, false, u.readArtistSnapshots(
+		ctx,
+		deduplicateOn(
+			ctx,
+			u.concurrency,
+			scraper.ArtistURLKey,
+			artistURLs,
+			u.runArtistPageReadJobs(ctx, artistsJobs),
+			artistsFromRatingsPage,
+			artistsFromRankingsPage,
+		),
+	))
+
+	return u.addStoredArtistImage(ctx, artists),
+		u.addStoredAlbumCover(ctx, u.deduplicateAlbums(ctx, albumsFromRatingsPage, albums)),
+		rankings
+}
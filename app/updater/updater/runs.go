@@ -0,0 +1,43 @@
+package updater
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/waelbendhia/scruffy/app/updater/database"
+)
+
+const (
+	RunKindUpdate  = "update"
+	RunKindReparse = "reparse"
+)
+
+// StartRun records the start of an update or reparse and returns its ID, the
+// changes the run makes are recorded against it.
+func (u *Updater) StartRun(ctx context.Context, kind string) (int64, error) {
+	u.contents.reset()
+
+	id, err := database.New(u.db).InsertUpdateRun(ctx, database.InsertUpdateRunParams{
+		Kind:      kind,
+		StartedAt: time.Now(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("could not insert update run: %w", err)
+	}
+
+	return id, nil
+}
+
+func (u *Updater) FinishRun(ctx context.Context, runID int64) error {
+	err := database.New(u.db).FinishUpdateRun(ctx, database.FinishUpdateRunParams{
+		EndedAt: sql.NullTime{Valid: true, Time: time.Now()},
+		ID:      runID,
+	})
+	if err != nil {
+		return fmt.Errorf("could not finish update run: %w", err)
+	}
+
+	return nil
+}
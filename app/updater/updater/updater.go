@@ -8,11 +8,13 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/waelbendhia/scruffy/app/updater/database"
 	"github.com/waelbendhia/scruffy/app/updater/logging"
 	"github.com/waelbendhia/scruffy/app/updater/scraper"
+	"github.com/waelbendhia/scruffy/app/updater/snapshot"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
@@ -30,6 +32,16 @@ func (u *Updater) error(ctx context.Context, err error, message string) {
 	}
 }
 
+// providerError reports err unless the provider ran out of time, a slow
+// provider only means fewer images to choose from.
+func (u *Updater) providerError(ctx context.Context, err error, message string) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		logging.GetLogger(ctx).With(zap.Error(err)).Warn(message)
+		return
+	}
+	u.error(ctx, err, message)
+}
+
 func (u *Updater) doRequest(ctx context.Context, req *http.Request) (*http.Response, error) {
 	if err := u.limiter.Do(ctx); err != nil {
 		return nil, err
@@ -40,9 +52,61 @@ func (u *Updater) doRequest(ctx context.Context, req *http.Request) (*http.Respo
 var (
 	ErrPageNotFound  = errors.New("page not found")
 	ErrPageUnchanged = errors.New("page has not changed since last update")
+	// ErrContentUnchanged is returned when a page changed but what was read
+	// from it did not.
+	ErrContentUnchanged = errors.New("page content has not changed since last update")
 )
 
+// setValidators makes req conditional on the page having changed since h was
+// stored.
+func setValidators(req *http.Request, h database.UpdateHistory) {
+	if h.Etag.Valid {
+		req.Header.Set("If-None-Match", h.Etag.String)
+	}
+	if h.LastModified.Valid {
+		req.Header.Set("If-Modified-Since", h.LastModified.Time.UTC().Format(http.TimeFormat))
+	}
+}
+
+// getPage fetches the page at pagePath. The request carries the validators
+// stored for the page, when the server reports that it has not been modified
+// the page is read from its snapshot and returned along with
+// ErrPageUnchanged. The page is fetched again without validators if it has no
+// snapshot.
 func (u *Updater) getPage(ctx context.Context, pagePath string) (*scraper.ScruffyPage, error) {
+	q := database.New(u.db)
+
+	h, err := q.GetUpdateHistory(ctx, pagePath)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("could not get update history: %w", err)
+	}
+
+	page, err := u.fetchPage(ctx, pagePath, h)
+	if !errors.Is(err, ErrPageUnchanged) {
+		return page, err
+	}
+
+	page, err = u.readSnapshot(ctx, q, pagePath)
+	if err != nil {
+		logging.GetLogger(ctx).With(zap.Error(err)).Debug("fetching unmodified page again")
+		return u.fetchPage(ctx, pagePath, database.UpdateHistory{})
+	}
+
+	// The validators are kept so that the next request is conditional too.
+	page.ETag = h.Etag.String
+	if h.LastModified.Valid {
+		page.LastModified = h.LastModified.Time
+	}
+
+	return page, ErrPageUnchanged
+}
+
+// fetchPage fetches the page at pagePath, the request is conditional on the
+// validators in h. ErrPageUnchanged is returned if the server reports that
+// the page has not been modified.
+func (u *Updater) fetchPage(
+	ctx context.Context, pagePath string, h database.UpdateHistory,
+) (*scraper.ScruffyPage, error) {
 	url, err := url.JoinPath(basePath, pagePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create url: %w", err)
@@ -53,6 +117,8 @@ func (u *Updater) getPage(ctx context.Context, pagePath string) (*scraper.Scruff
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	setValidators(req, h)
+
 	resp, err := u.doRequest(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to do request: %w", err)
@@ -61,6 +127,8 @@ func (u *Updater) getPage(ctx context.Context, pagePath string) (*scraper.Scruff
 	defer resp.Body.Close()
 
 	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return nil, ErrPageUnchanged
 	case resp.StatusCode == http.StatusNotFound:
 		return nil, ErrPageNotFound
 	case resp.StatusCode < http.StatusOK || resp.StatusCode > http.StatusMultipleChoices:
@@ -79,17 +147,58 @@ func (u *Updater) getPage(ctx context.Context, pagePath string) (*scraper.Scruff
 	return page, nil
 }
 
+// PruneSnapshots deletes the page snapshots that fall outside of the
+// updater's retention policy.
+func (u *Updater) PruneSnapshots(ctx context.Context) error {
+	n, err := snapshot.Prune(ctx, u.db, u.snapshotRetention)
+	if err != nil {
+		return err
+	}
+
+	logging.GetLogger(ctx).With(zap.Int64("count", n)).Info("pruned page snapshots")
+
+	return nil
+}
+
 func (u *Updater) upsertPage(
 	ctx context.Context, pagePath string, page *scraper.ScruffyPage,
 ) error {
+	now := time.Now()
+	q := database.New(u.db)
+
+	snapshotHash := sql.NullString{}
+	if page.Raw != nil {
+		if err := snapshot.Save(ctx, q, pagePath, page.Hash, page.Raw, now); err != nil {
+			u.error(ctx, err, "could not save page snapshot")
+		} else {
+			snapshotHash = sql.NullString{Valid: true, String: page.Hash}
+		}
+	}
+
+	etag := sql.NullString{Valid: page.ETag != "", String: page.ETag}
+	lastModified := sql.NullTime{Valid: !page.LastModified.IsZero(), Time: page.LastModified}
+
 	params := database.UpsertUpdateHistoryParams{
-		CheckedOn: time.Now(),
-		Hash:      page.Hash,
-		PageURL:   pagePath,
+		CheckedOn:    now,
+		Hash:         page.Hash,
+		PageURL:      pagePath,
+		SnapshotHash: snapshotHash,
+		Etag:         etag,
+		LastModified: lastModified,
 	}
-	_, err := database.New(u.db).UpsertUpdateHistory(ctx, params)
+	_, err := q.UpsertUpdateHistory(ctx, params)
 	switch {
 	case errors.Is(err, sql.ErrNoRows):
+		err := q.RefreshUpdateHistory(ctx, database.RefreshUpdateHistoryParams{
+			Snapshothash: snapshotHash,
+			Etag:         etag,
+			LastModified: lastModified,
+			PageURL:      pagePath,
+			Hash:         page.Hash,
+		})
+		if err != nil {
+			u.error(ctx, err, "could not refresh update history")
+		}
 		return ErrPageUnchanged
 	case err != nil:
 		return fmt.Errorf("failed to read page '%s': %w", pagePath, err)
@@ -98,30 +207,59 @@ func (u *Updater) upsertPage(
 	}
 }
 
-func (u *Updater) readAllArtistsPages(ctx context.Context) <-chan artistsPageReadJob {
+// upsertContent records hash as the content hash of the page at pagePath and
+// returns ErrContentUnchanged if it already was.
+func (u *Updater) upsertContent(ctx context.Context, pagePath, hash string) error {
+	n, err := database.New(u.db).UpdateContentHash(ctx, database.UpdateContentHashParams{
+		ContentHash: sql.NullString{Valid: true, String: hash},
+		PageURL:     pagePath,
+	})
+	switch {
+	case err != nil:
+		return fmt.Errorf("could not update content hash: %w", err)
+	case n == 0:
+		return ErrContentUnchanged
+	default:
+		return nil
+	}
+}
+
+// readIndexPages fetches the registry's seed pages and every page discovered
+// from them, then dispatches each page to its reader.
+func (u *Updater) readIndexPages(
+	ctx context.Context, filterUnchanged bool,
+) (<-chan artistsPageReadJob, <-chan ratingsPageReadJob, <-chan rankingPageReadJob) {
 	g, ctx := errgroup.WithContext(ctx)
 
-	rs := map[string]scraper.PageReader{
-		"/music/groups.html":  scraper.ReadArtistsFromRockPage,
-		"/jazz/musician.html": scraper.ReadArtistsFromJazzPage,
-		"/vol1/":              scraper.ReadArtistsFromVolumePage(1),
-		"/vol2/":              scraper.ReadArtistsFromVolumePage(2),
-		"/vol3/":              scraper.ReadArtistsFromVolumePage(3),
-		"/vol4/":              scraper.ReadArtistsFromVolumePage(4),
-		"/vol5/":              scraper.ReadArtistsFromVolumePage(5),
-		"/vol6/":              scraper.ReadArtistsFromVolumePage(6),
-		"/vol7/":              scraper.ReadArtistsFromVolumePage(7),
-		"/vol8/":              scraper.ReadArtistsFromVolumePage(8),
-	}
+	outArtists := make(chan artistsPageReadJob, u.concurrency)
+	outRatings := make(chan ratingsPageReadJob, u.concurrency)
+	outRankings := make(chan rankingPageReadJob, u.concurrency)
 
-	out := make(chan artistsPageReadJob, u.concurrency)
+	var (
+		seenLock sync.Mutex
+		seen     = map[string]struct{}{}
+	)
+	markSeen := func(p string) bool {
+		seenLock.Lock()
+		defer seenLock.Unlock()
+		_, ok := seen[p]
+		seen[p] = struct{}{}
+		return !ok
+	}
 
-	for p, r := range rs {
-		p, r := p, r
+	var readPage func(p string)
+	readPage = func(p string) {
 		g.Go(func() error {
 			log := logging.GetLogger(ctx)
 			log = log.With(zap.String("page", p))
 			page, err := u.getPage(ctx, p)
+			unchanged := errors.Is(err, ErrPageUnchanged)
+			if unchanged {
+				// The server did not send the page, it was read from its
+				// snapshot.
+				log.Debug("page not modified")
+				err = nil
+			}
 			if err != nil {
 				if !errors.Is(err, context.Canceled) {
 					log.With(zap.Error(err)).Warn("failed to get page")
@@ -129,62 +267,56 @@ func (u *Updater) readAllArtistsPages(ctx context.Context) <-chan artistsPageRea
 				return nil
 			}
 
-			select {
-			case out <- artistsPageReadJob{page: page, path: p, reader: r}:
-			case <-ctx.Done():
+			for _, dp := range u.registry.DiscoverPages(ctx, p, page.Doc) {
+				if markSeen(dp) {
+					log.With(zap.String("discovered", dp)).Debug("discovered page")
+					readPage(dp)
+				}
 			}
 
-			return nil
-		})
-	}
-
-	go func() { defer close(out); g.Wait() }()
-
-	return out
-}
-
-func (u *Updater) readAlbumPages(ctx context.Context) <-chan ratingsPageReadJob {
-	rs := map[string]scraper.CDReviewReader{
-		"/cdreview/new.html": scraper.ReadNewRatingsPage(),
-	}
-
-	for y := 1990; y <= time.Now().Year(); y++ {
-		y := y
-		if y < 2000 {
-			rs[fmt.Sprintf("/cdreview/%d.html", y)] = scraper.Read90sCDReviewPage(y)
-		} else {
-			rs[fmt.Sprintf("/cdreview/%d.html", y)] = scraper.Read2000sCDReviewPage(y)
-		}
-	}
+			if unchanged && filterUnchanged {
+				return nil
+			}
 
-	out := make(chan ratingsPageReadJob, u.concurrency)
+			if r, ok := u.registry.PageReader(p); ok {
+				select {
+				case outArtists <- artistsPageReadJob{page: page, path: p, reader: r}:
+				case <-ctx.Done():
+				}
+			}
 
-	g, ctx := errgroup.WithContext(ctx)
-	for p, r := range rs {
-		p, r := p, r
-		g.Go(func() error {
-			log := logging.GetLogger(ctx)
-			log = log.With(zap.String("page", p))
-			page, err := u.getPage(ctx, p)
-			if err != nil {
-				if !errors.Is(err, context.Canceled) {
-					log.With(zap.Error(err)).Error("failed to get page")
+			if r, ok := u.registry.CDReviewReader(p); ok {
+				select {
+				case outRatings <- ratingsPageReadJob{page: page, path: p, reader: r}:
+				case <-ctx.Done():
 				}
-				return nil
 			}
 
-			select {
-			case out <- ratingsPageReadJob{page: page, path: p, reader: r}:
-			case <-ctx.Done():
+			if r, ok := u.registry.RankingReader(p); ok {
+				select {
+				case outRankings <- rankingPageReadJob{page: page, path: p, reader: r}:
+				case <-ctx.Done():
+				}
 			}
 
 			return nil
 		})
 	}
 
-	go func() { defer close(out); g.Wait() }()
+	for _, p := range u.registry.Seeds() {
+		if markSeen(p) {
+			readPage(p)
+		}
+	}
 
-	return out
+	go func() {
+		defer close(outArtists)
+		defer close(outRatings)
+		defer close(outRankings)
+		g.Wait()
+	}()
+
+	return outArtists, outRatings, outRankings
 }
 
 func (u *Updater) doConcurrently(ctx context.Context, onFinish func(), f func() error) {
@@ -195,52 +327,82 @@ func (u *Updater) doConcurrently(ctx context.Context, onFinish func(), f func()
 	go func() { defer onFinish(); g.Wait() }()
 }
 
+// GetAllArtistsAndRatings crawls the index, ratings and ranking pages. It
+// returns the artists they list, the albums read from the ratings pages and
+// the rankings.
 func (u *Updater) GetAllArtistsAndRatings(
 	ctx context.Context, filterUnchanged bool,
-) (<-chan string, <-chan scraper.Album) {
-	albumJobs := u.readAlbumPages(ctx)
-	artistJobs := u.readAllArtistsPages(ctx)
+) (<-chan string, <-chan scraper.Album, <-chan scraper.Ranking) {
+	artistJobs, albumJobs, rankingJobs := u.readIndexPages(ctx, filterUnchanged)
 
 	filteredAlbumJobs := filterPageReadJobs(ctx, u, albumJobs, filterUnchanged)
 	filteredArtistJobs := filterPageReadJobs(ctx, u, artistJobs, filterUnchanged)
+	filteredRankingJobs := filterPageReadJobs(ctx, u, rankingJobs, filterUnchanged)
 
-	artistsFromRatingsPage, albumsFromRatingsPage := u.runRatingsPageReadJobs(ctx, filteredAlbumJobs)
+	artistsFromRatingsPage, albumsFromRatingsPage := u.runRatingsPageReadJobs(
+		ctx, filterUnchanged, filteredAlbumJobs,
+	)
+	artistsFromRankingsPage, rankings := u.runRankingPageReadJobs(
+		ctx, filterUnchanged, filteredRankingJobs,
+	)
 
 	artists := deduplicateOn(
 		ctx,
 		u.concurrency,
-		func(s string) string { return s },
+		scraper.ArtistURLKey,
 		u.runArtistPageReadJobs(ctx, filteredArtistJobs),
 		artistsFromRatingsPage,
+		artistsFromRankingsPage,
 	)
-	albums := deduplicateOn(
-		ctx,
-		u.concurrency,
-		func(a scraper.Album) string {
-			return fmt.Sprintf("%s - %s", a.Name, a.ArtistURL)
-		},
-		albumsFromRatingsPage,
-	)
+	albums := deduplicateOn(ctx, u.concurrency, newAlbumKeys().key, albumsFromRatingsPage)
+
+	return artists, albums, rankings
+}
+
+// readArtistPage fetches the artist page at artistURL. It returns the artist
+// pages the page links to and whether the page should be read.
+func (u *Updater) readArtistPage(
+	ctx context.Context, filterUnchanged bool, artistURL string,
+) (*scraper.ScruffyPage, []string, bool) {
+	log := logging.GetLogger(ctx).With(zap.String("artist-url", artistURL))
+
+	page, err := u.getPage(ctx, artistURL)
+	switch {
+	case errors.Is(err, ErrPageUnchanged):
+		log.Debug("page not modified")
+		links := scraper.ReadArtistLinks(artistURL, page.Doc)
+		return page, links, !filterUnchanged
+	case errors.Is(err, ErrPageNotFound):
+		log.Warn("artist page not found")
+		u.markArtistNotFound(ctx, artistURL)
+		return nil, nil, false
+	case err != nil:
+		if !errors.Is(err, context.Canceled) {
+			log.With(zap.Error(err)).Error("could not get artist page")
+		}
+		return nil, nil, false
+	}
 
-	return artists, albums
+	return page, scraper.ReadArtistLinks(artistURL, page.Doc), true
 }
 
-func (u *Updater) readArtistPages(ctx context.Context, in <-chan string) <-chan artistPageReadJob {
+func (u *Updater) readArtistPages(
+	ctx context.Context, d *artistDiscovery, filterUnchanged bool, in <-chan string,
+) <-chan artistPageReadJob {
 	g, ctx := errgroup.WithContext(ctx)
 	out := make(chan artistPageReadJob, u.concurrency)
 
 	for i := 0; i < u.concurrency; i++ {
 		g.Go(func() error {
-			log := logging.GetLogger(ctx)
 			for a := range in {
-				a := a
-				log := log.With(zap.String("artist-url", a))
-				page, err := u.getPage(ctx, a)
-				if err != nil {
-					if !errors.Is(err, context.Canceled) {
-						log.With(zap.Error(err)).
-							Error("could not get artist page")
-					}
+				page, links, ok := u.readArtistPage(ctx, filterUnchanged, a)
+				if d != nil {
+					// Discovery only ends once every page it was given is
+					// visited, so pages are visited whether they are read or
+					// not.
+					d.visit(ctx, a, links)
+				}
+				if !ok {
 					continue
 				}
 
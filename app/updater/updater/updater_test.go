@@ -0,0 +1,80 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/waelbendhia/scruffy/app/updater/provider"
+	"github.com/waelbendhia/scruffy/app/updater/scraper"
+)
+
+// failingProvider fails every search with err.
+type failingProvider struct{ err error }
+
+var _ interface {
+	provider.AlbumProvider
+	provider.ArtistProvider
+} = failingProvider{}
+
+func (failingProvider) Name() string { return "failing" }
+
+func (fp failingProvider) SearchAlbums(context.Context, string, string) ([]provider.AlbumResult, error) {
+	return nil, fp.err
+}
+func (failingProvider) AlbumEnabled() bool { return true }
+func (failingProvider) AlbumDisable()      {}
+func (failingProvider) AlbumEnable()       {}
+
+func (fp failingProvider) SearchArtists(context.Context, string) ([]provider.ArtistResult, error) {
+	return nil, fp.err
+}
+func (failingProvider) ArtistEnabled() bool { return true }
+func (failingProvider) ArtistDisable()      {}
+func (failingProvider) ArtistEnable()       {}
+
+type providerErrorTest struct {
+	name string
+	err  error
+	// reported is the number of errors that reach the error hook, one per
+	// search.
+	reported int32
+}
+
+func (pt *providerErrorTest) run(t *testing.T) {
+	var reported atomic.Int32
+	p := failingProvider{err: pt.err}
+	u := NewUpdater(
+		nil,
+		WithErrorHook(func(error) { reported.Add(1) }),
+		AddAlbumProvider(1, p),
+		AddArtistProvider(1, p),
+	)
+
+	ctx := context.Background()
+	u.getAlbumImageAndYear(ctx, "Can", "Tago Mago")
+	u.getArtistImage(ctx, scraper.Artist{Name: "Can"})
+
+	if n := reported.Load(); n != pt.reported {
+		t.Errorf("expected %d errors to be reported got %d", pt.reported, n)
+	}
+}
+
+func TestProviderErrors(t *testing.T) {
+	tts := []providerErrorTest{
+		{name: "deadline", err: context.DeadlineExceeded, reported: 0},
+		{
+			name:     "request deadline",
+			err:      fmt.Errorf("request failed: %w", context.DeadlineExceeded),
+			reported: 0,
+		},
+		{name: "cancelled", err: context.Canceled, reported: 0},
+		{name: "failed", err: errors.New("unhandled response status 500"), reported: 2},
+	}
+
+	for _, tt := range tts {
+		t.Run(tt.name, tt.run)
+	}
+}
@@ -0,0 +1,2 @@
+-- AlterTable
+ALTER TABLE "Artist" ADD COLUMN "bioMarkdown" TEXT;
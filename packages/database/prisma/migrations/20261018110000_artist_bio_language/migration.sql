@@ -0,0 +1,2 @@
+-- AlterTable
+ALTER TABLE "Artist" ADD COLUMN "bioLanguage" TEXT NOT NULL DEFAULT 'en';
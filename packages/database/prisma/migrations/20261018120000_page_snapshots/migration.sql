@@ -0,0 +1,20 @@
+-- CreateTable
+CREATE TABLE "PageSnapshot" (
+    "hash" TEXT NOT NULL PRIMARY KEY,
+    "content" BLOB NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- CreateTable
+CREATE TABLE "PageVersion" (
+    "pageURL" TEXT NOT NULL,
+    "hash" TEXT NOT NULL,
+    "firstSeen" DATETIME NOT NULL,
+    "lastSeen" DATETIME NOT NULL,
+
+    PRIMARY KEY ("pageURL", "hash"),
+    CONSTRAINT "PageVersion_hash_fkey" FOREIGN KEY ("hash") REFERENCES "PageSnapshot" ("hash") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- AlterTable
+ALTER TABLE "UpdateHistory" ADD COLUMN "snapshotHash" TEXT REFERENCES "PageSnapshot" ("hash") ON DELETE SET NULL ON UPDATE CASCADE;
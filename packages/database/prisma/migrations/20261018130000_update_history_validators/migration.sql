@@ -0,0 +1,3 @@
+-- AlterTable
+ALTER TABLE "UpdateHistory" ADD COLUMN "etag" TEXT;
+ALTER TABLE "UpdateHistory" ADD COLUMN "lastModified" DATETIME;
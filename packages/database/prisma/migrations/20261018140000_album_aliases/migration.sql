@@ -0,0 +1,13 @@
+-- CreateTable
+CREATE TABLE "AlbumAlias" (
+    "artistUrl" TEXT NOT NULL,
+    "alias" TEXT NOT NULL,
+    "normalized" TEXT NOT NULL,
+    "name" TEXT NOT NULL,
+
+    PRIMARY KEY ("artistUrl", "alias"),
+    CONSTRAINT "AlbumAlias_artistUrl_name_fkey" FOREIGN KEY ("artistUrl", "name") REFERENCES "Album" ("artistUrl", "name") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE INDEX "AlbumAlias_artistUrl_normalized_idx" ON "AlbumAlias"("artistUrl", "normalized");
@@ -0,0 +1,4 @@
+-- AlterTable
+ALTER TABLE "Album" ADD COLUMN "position" INTEGER;
+ALTER TABLE "Album" ADD COLUMN "kind" TEXT;
+ALTER TABLE "Album" ADD COLUMN "credits" TEXT;
@@ -0,0 +1,7 @@
+-- AlterTable
+ALTER TABLE "Artist" ADD COLUMN "notFoundAt" DATETIME;
+ALTER TABLE "Artist" ADD COLUMN "deletedAt" DATETIME;
+
+-- AlterTable
+ALTER TABLE "Album" ADD COLUMN "seenAt" DATETIME;
+ALTER TABLE "Album" ADD COLUMN "deletedAt" DATETIME;
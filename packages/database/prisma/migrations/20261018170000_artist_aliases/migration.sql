@@ -0,0 +1,10 @@
+-- CreateTable
+CREATE TABLE "ArtistAlias" (
+    "alias" TEXT NOT NULL PRIMARY KEY,
+    "url" TEXT NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "ArtistAlias_url_fkey" FOREIGN KEY ("url") REFERENCES "Artist" ("url") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE INDEX "ArtistAlias_url_idx" ON "ArtistAlias"("url");
@@ -0,0 +1,24 @@
+-- CreateTable
+CREATE TABLE "UpdateRun" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "kind" TEXT NOT NULL,
+    "startedAt" DATETIME NOT NULL,
+    "endedAt" DATETIME
+);
+
+-- CreateTable
+CREATE TABLE "RatingHistory" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "artistUrl" TEXT NOT NULL,
+    "albumName" TEXT NOT NULL,
+    "oldRating" DECIMAL NOT NULL,
+    "newRating" DECIMAL NOT NULL,
+    "pageURL" TEXT NOT NULL,
+    "runId" INTEGER,
+    "changedAt" DATETIME NOT NULL,
+    CONSTRAINT "RatingHistory_artistUrl_albumName_fkey" FOREIGN KEY ("artistUrl", "albumName") REFERENCES "Album" ("artistUrl", "name") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "RatingHistory_runId_fkey" FOREIGN KEY ("runId") REFERENCES "UpdateRun" ("id") ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE INDEX "RatingHistory_artistUrl_albumName_idx" ON "RatingHistory"("artistUrl", "albumName");
@@ -0,0 +1,14 @@
+-- CreateTable
+CREATE TABLE "BioRevision" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "artistUrl" TEXT NOT NULL,
+    "bio" TEXT NOT NULL,
+    "bioMarkdown" TEXT,
+    "runId" INTEGER,
+    "createdAt" DATETIME NOT NULL,
+    CONSTRAINT "BioRevision_artistUrl_fkey" FOREIGN KEY ("artistUrl") REFERENCES "Artist" ("url") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "BioRevision_runId_fkey" FOREIGN KEY ("runId") REFERENCES "UpdateRun" ("id") ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE INDEX "BioRevision_artistUrl_idx" ON "BioRevision"("artistUrl");
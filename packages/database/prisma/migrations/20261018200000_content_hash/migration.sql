@@ -0,0 +1,2 @@
+-- AlterTable
+ALTER TABLE "UpdateHistory" ADD COLUMN "contentHash" TEXT;
@@ -0,0 +1,17 @@
+-- CreateTable
+CREATE TABLE "PageParse" (
+    "pageURL" TEXT NOT NULL PRIMARY KEY,
+    "albums" INTEGER,
+    "bioLength" INTEGER,
+    "links" INTEGER,
+    "parsedAt" DATETIME NOT NULL,
+    CONSTRAINT "PageParse_pageURL_fkey" FOREIGN KEY ("pageURL") REFERENCES "UpdateHistory" ("pageURL") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- CreateTable
+CREATE TABLE "PageQuarantine" (
+    "pageURL" TEXT NOT NULL PRIMARY KEY,
+    "reason" TEXT NOT NULL,
+    "quarantinedAt" DATETIME NOT NULL,
+    CONSTRAINT "PageQuarantine_pageURL_fkey" FOREIGN KEY ("pageURL") REFERENCES "UpdateHistory" ("pageURL") ON DELETE CASCADE ON UPDATE CASCADE
+);
@@ -0,0 +1,11 @@
+-- CreateTable
+CREATE TABLE "PageDiagnostics" (
+    "pageURL" TEXT NOT NULL PRIMARY KEY,
+    "nameStrategy" TEXT,
+    "bioStrategy" TEXT,
+    "albumMisses" INTEGER NOT NULL,
+    "skippedRows" INTEGER NOT NULL,
+    "diagnostics" TEXT NOT NULL,
+    "recordedAt" DATETIME NOT NULL,
+    CONSTRAINT "PageDiagnostics_pageURL_fkey" FOREIGN KEY ("pageURL") REFERENCES "UpdateHistory" ("pageURL") ON DELETE CASCADE ON UPDATE CASCADE
+);
@@ -0,0 +1,27 @@
+-- CreateTable
+CREATE TABLE "Ranking" (
+    "pageURL" TEXT NOT NULL PRIMARY KEY,
+    "title" TEXT NOT NULL,
+    "lastModified" DATETIME NOT NULL,
+    CONSTRAINT "Ranking_pageURL_fkey" FOREIGN KEY ("pageURL") REFERENCES "UpdateHistory" ("pageURL") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- CreateTable
+CREATE TABLE "RankingEntry" (
+    "rankingUrl" TEXT NOT NULL,
+    "position" INTEGER NOT NULL,
+    "rank" INTEGER NOT NULL,
+    "artistName" TEXT NOT NULL,
+    "albumTitle" TEXT NOT NULL,
+    "year" INTEGER,
+    "artistUrl" TEXT,
+    "albumName" TEXT,
+
+    PRIMARY KEY ("rankingUrl", "position"),
+    CONSTRAINT "RankingEntry_rankingUrl_fkey" FOREIGN KEY ("rankingUrl") REFERENCES "Ranking" ("pageURL") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "RankingEntry_artistUrl_fkey" FOREIGN KEY ("artistUrl") REFERENCES "Artist" ("url") ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT "RankingEntry_artistUrl_albumName_fkey" FOREIGN KEY ("artistUrl", "albumName") REFERENCES "Album" ("artistUrl", "name") ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE INDEX "RankingEntry_artistUrl_albumName_idx" ON "RankingEntry"("artistUrl", "albumName");
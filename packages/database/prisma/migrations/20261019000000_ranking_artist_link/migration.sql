@@ -0,0 +1,2 @@
+-- AlterTable
+ALTER TABLE "RankingEntry" ADD COLUMN "artistLink" TEXT;
@@ -0,0 +1,9 @@
+-- CreateTable
+CREATE TABLE "ArtistExternalID" (
+    "artistUrl" TEXT NOT NULL,
+    "service" TEXT NOT NULL,
+    "externalId" TEXT NOT NULL,
+
+    PRIMARY KEY ("artistUrl", "service"),
+    CONSTRAINT "ArtistExternalID_artistUrl_fkey" FOREIGN KEY ("artistUrl") REFERENCES "Artist" ("url") ON DELETE CASCADE ON UPDATE CASCADE
+);